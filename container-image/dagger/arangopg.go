@@ -3,49 +3,36 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 )
 
-// CreateArangoPostgresContainer creates a container based on ArangoDB 3.10.14,
-// updates it, installs PostgreSQL 14, and restic on the linux/amd64 platform
+const resticVersion = "0.17.0"
+
+// CreateArangoPostgresContainer creates a container based on ArangoDB 3.11.6,
+// updates it, installs PostgreSQL 14, and restic on the first configured
+// platform, linux/amd64 by default
 func (cmg *ContainerImage) CreateArangoPostgresContainer(
 	ctx context.Context,
 ) (*Container, error) {
-	return dag.Container(ContainerOpts{Platform: "linux/amd64"}).
-			From("arangodb:3.11.6").
-			WithExec([]string{"apk", "update"}).
-			WithExec([]string{"apk", "add", "postgresql14", "curl", "bzip2", "redis"}).
-			WithExec([]string{"curl", "-L", "https://github.com/restic/restic/releases/download/v0.17.0/restic_0.17.0_linux_amd64.bz2", "-o", "/tmp/restic_0.17.0_linux_amd64.bz2"}).
-			WithExec([]string{"bunzip2", "/tmp/restic_0.17.0_linux_amd64.bz2"}).
-			WithExec([]string{"mv", "/tmp/restic_0.17.0_linux_amd64", "/usr/local/bin/restic"}).
-			WithExec([]string{"chmod", "+x", "/usr/local/bin/restic"}).
-			WithExec([]string{"rm", "-f", "/tmp/restic_0.17.0_linux_amd64.bz2"}),
-		nil
+	return createArangoPostgresContainer(cmg.arangoPostgresPlatforms()[0]), nil
 }
 
 // BuildAndPublishArangoPostgresContainer builds and publishes the ArangoPostgres container
 func (cmg *ContainerImage) BuildAndPublishArangoPostgresContainer(
 	ctx context.Context,
-	// dockerhub user name
+	// registry user name, ignored when set by WithRegistry
+	// +optional
 	user string,
-	// dockerhub password, use an api token
+	// registry password, use an api token, ignored when set by WithRegistry
+	// +optional
 	password string,
 ) (string, error) {
-	container, err := cmg.CreateArangoPostgresContainer(ctx)
-	if err != nil {
-		return "", fmt.Errorf(
-			"error creating ArangoPostgres container: %w",
-			err,
-		)
+	var variants []*Container
+	for _, platform := range cmg.arangoPostgresPlatforms() {
+		variants = append(variants, createArangoPostgresContainer(platform))
 	}
-
-	tag := fmt.Sprintf("%s/%s:%s", cmg.Namespace, cmg.Image, cmg.Ref)
-	_, err = container.
-		WithRegistryAuth(
-			"docker.io",
-			user,
-			dag.SetSecret("docker-pass", password),
-		).
-		Publish(ctx, tag)
+	tag := cmg.imageRef(cmg.Namespace, cmg.Image, cmg.Ref)
+	_, err := cmg.publishVariants(ctx, variants, tag, user, password)
 	if err != nil {
 		return "", fmt.Errorf(
 			"error publishing ArangoPostgres container: %w",
@@ -55,3 +42,41 @@ func (cmg *ContainerImage) BuildAndPublishArangoPostgresContainer(
 
 	return tag, nil
 }
+
+// arangoPostgresPlatforms returns the configured platforms, linux/amd64 when
+// none is set.
+func (cmg *ContainerImage) arangoPostgresPlatforms() []Platform {
+	if len(cmg.Platforms) == 0 {
+		return []Platform{"linux/amd64"}
+	}
+	platforms := make([]Platform, 0, len(cmg.Platforms))
+	for _, platform := range cmg.Platforms {
+		platforms = append(platforms, Platform(platform))
+	}
+	return platforms
+}
+
+// createArangoPostgresContainer builds the ArangoPostgres container for a
+// single platform with the matching restic binary.
+func createArangoPostgresContainer(platform Platform) *Container {
+	restic := fmt.Sprintf("restic_%s_linux_%s", resticVersion, resticArch(platform))
+	return dag.Container(ContainerOpts{Platform: platform}).
+		From("arangodb:3.11.6").
+		WithExec([]string{"apk", "update"}).
+		WithExec([]string{"apk", "add", "postgresql14", "curl", "bzip2", "redis"}).
+		WithExec([]string{"curl", "-L", fmt.Sprintf("https://github.com/restic/restic/releases/download/v%s/%s.bz2", resticVersion, restic), "-o", fmt.Sprintf("/tmp/%s.bz2", restic)}).
+		WithExec([]string{"bunzip2", fmt.Sprintf("/tmp/%s.bz2", restic)}).
+		WithExec([]string{"mv", fmt.Sprintf("/tmp/%s", restic), "/usr/local/bin/restic"}).
+		WithExec([]string{"chmod", "+x", "/usr/local/bin/restic"}).
+		WithExec([]string{"rm", "-f", fmt.Sprintf("/tmp/%s.bz2", restic)})
+}
+
+// resticArch maps a platform to the architecture naming of the restic
+// releases.
+func resticArch(platform Platform) string {
+	parts := strings.Split(string(platform), "/")
+	if len(parts) < 2 {
+		return "amd64"
+	}
+	return parts[1]
+}
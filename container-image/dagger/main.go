@@ -36,6 +36,14 @@ type ContainerImage struct {
 	Image string
 	// Name of the docker image tag
 	DockerImageTag string
+	// Address of the registry the images are published to
+	Registry string
+	// User name of the registry
+	RegistryUser string
+	// Password of the registry
+	RegistryPassword *Secret
+	// Platforms the images are built for
+	Platforms []string
 }
 
 // Payload represents the payload information for a deployment
@@ -118,52 +126,65 @@ func (cmg *ContainerImage) WithImage(
 	return cmg
 }
 
-// PublishFromRepo publishes a container image to Docker Hub
+// PublishFromRepo publishes a container image to the configured registry,
+// Docker Hub by default. It returns the published reference@digest; for
+// several platforms it is followed by one "os/arch digest" line per platform.
 func (cmg *ContainerImage) PublishFromRepo(
 	ctx context.Context,
-	// dockerhub user name
+	// registry user name, ignored when set by WithRegistry
+	// +optional
 	user string,
-	// dockerhub password, use an api token
+	// registry password, use an api token, ignored when set by WithRegistry
+	// +optional
 	password string,
 ) (string, error) {
-	cont, err := cmg.GenerateImageTag(ctx)
+	source, err := cmg.taggedSource(ctx)
 	if err != nil {
 		return "", err
 	}
-	_, err = cont.WithRegistryAuth("docker.io", user, dag.SetSecret("docker-pass", password)).
-		Publish(ctx, fmt.Sprintf(
-			"%s/%s:%s",
-			cmg.Namespace,
-			cmg.Image,
-			cmg.DockerImageTag,
-		))
-	if err != nil {
-		return "", fmt.Errorf("error in publishing docker container %s", err)
-	}
-	return cmg.DockerImageTag, nil
+	variants := cmg.buildVariants(func(platform Platform) *Container {
+		return dag.Container(ContainerOpts{Platform: platform}).
+			Build(source, ContainerBuildOpts{Dockerfile: cmg.Dockerfile})
+	})
+	return cmg.publishReport(
+		ctx,
+		variants,
+		cmg.imageRef(cmg.Namespace, cmg.Image, cmg.DockerImageTag),
+		user,
+		password,
+	)
 }
 
-// PublishFromRepoWithDeploymentID publishes a container image to Docker Hub
-// using deployment information from a specified GitHub deployment ID.
+// PublishFromRepoWithDeploymentID publishes a container image to the
+// configured registry using deployment information from a specified GitHub
+// deployment ID. It returns the published reference@digest; for several
+// platforms it is followed by one "os/arch digest" line per platform.
 func (cmg *ContainerImage) PublishFromRepoWithDeploymentID(
 	ctx context.Context,
-	// dockerhub user name
+	// registry user name, ignored when set by WithRegistry
+	// +optional
 	user string,
-	// dockerhub password, use an api token
+	// registry password, use an api token, ignored when set by WithRegistry
+	// +optional
 	password string,
 	// deployment ID
 	deploymentID string,
 	// GitHub token for making API requests
 	token string,
-) error {
+) (string, error) {
 	return cmg.publishFromRepoWithDeploymentIDCommon(
 		ctx,
 		user,
 		password,
 		deploymentID,
 		token,
-		func(source *Directory, dpl *github.Deployment, pload Payload) *Container {
-			return dag.Container().
+		func(
+			platform Platform,
+			source *Directory,
+			dpl *github.Deployment,
+			pload Payload,
+		) *Container {
+			return dag.Container(ContainerOpts{Platform: platform}).
 				Build(source, ContainerBuildOpts{Dockerfile: pload.Dockerfile})
 		},
 	)
@@ -183,7 +204,7 @@ func (cmg *ContainerImage) FakePublishFromRepo(
 			"ttl.sh/%s-%s-%s:10m",
 			cmg.Namespace,
 			cmg.Image,
-			cmg.GenerateImageTag,
+			cmg.DockerImageTag,
 		),
 	)
 }
@@ -192,6 +213,19 @@ func (cmg *ContainerImage) FakePublishFromRepo(
 func (cmg *ContainerImage) GenerateImageTag(
 	ctx context.Context,
 ) (*Container, error) {
+	source, err := cmg.taggedSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dag.Container().
+		Build(source, ContainerBuildOpts{Dockerfile: cmg.Dockerfile}), nil
+}
+
+// taggedSource checks out the git reference and derives the docker image tag
+// from it.
+func (cmg *ContainerImage) taggedSource(
+	ctx context.Context,
+) (*Directory, error) {
 	source := dag.Gitter().
 		WithRef(cmg.Ref).
 		WithRepository(cmg.Repository).
@@ -213,8 +247,7 @@ func (cmg *ContainerImage) GenerateImageTag(
 		genTag = dtag
 	}
 	cmg.DockerImageTag = genTag
-	return dag.Container().
-		Build(source, ContainerBuildOpts{Dockerfile: cmg.Dockerfile}), nil
+	return source, nil
 }
 
 func (cmg *ContainerImage) generateDefaultTag(
@@ -256,26 +289,30 @@ func parseOwnerRepo(ownerRepo string) (string, string, error) {
 	return parts[0], parts[1], nil
 }
 
-// PublishFrontendFromRepoWithDeploymentID publishes a frontend container image to Docker Hub
-// using deployment information from a specified GitHub deployment ID.
+// PublishFrontendFromRepoWithDeploymentID publishes a frontend container image
+// to the configured registry using deployment information from a specified
+// GitHub deployment ID. It returns the published reference of every image
+// with the digest of every platform.
 func (cmg *ContainerImage) PublishFrontendFromRepoWithDeploymentID(
 	ctx context.Context,
-	// dockerhub user name
+	// registry user name, ignored when set by WithRegistry
+	// +optional
 	user string,
-	// dockerhub password, use an api token
+	// registry password, use an api token, ignored when set by WithRegistry
+	// +optional
 	password string,
 	// deployment ID
 	deploymentID string,
 	// GitHub token for making API requests
 	token string,
-) error {
+) (string, error) {
 	owner, repo, err := parseOwnerRepo(cmg.Repository)
 	if err != nil {
-		return err
+		return "", err
 	}
 	depId, err := strconv.ParseInt(deploymentID, 10, 64)
 	if err != nil {
-		return fmt.Errorf("error in converting string to int64 %s", err)
+		return "", fmt.Errorf("error in converting string to int64 %s", err)
 	}
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
@@ -288,17 +325,17 @@ func (cmg *ContainerImage) PublishFrontendFromRepoWithDeploymentID(
 		depId,
 	)
 	if err != nil {
-		return fmt.Errorf(
+		return "", fmt.Errorf(
 			"error in getting deployment information: %s",
 			err,
 		)
 	}
 	var pload Payload
 	if err := json.Unmarshal(deployment.Payload, &pload); err != nil {
-		return fmt.Errorf("error in decoding payload %s", err)
+		return "", fmt.Errorf("error in decoding payload %s", err)
 	}
 	if pload.Repository != cmg.Repository {
-		return fmt.Errorf(
+		return "", fmt.Errorf(
 			"payload repo %s and given repo %s does not match",
 			pload.Repository,
 			cmg.Repository,
@@ -310,36 +347,44 @@ func (cmg *ContainerImage) PublishFrontendFromRepoWithDeploymentID(
 		Checkout()
 	allImages := strings.Split(pload.DockerImage, ":")
 	allDockerfiles := strings.Split(pload.Dockerfile, ":")
+	reports := make([]string, len(allDockerfiles))
 	grp, ctx := errgroup.WithContext(ctx)
 	for idx, file := range allDockerfiles {
 		grp.Go(func() error {
-			_, err := dag.Container().
-				Build(source, ContainerBuildOpts{
-					Dockerfile: file,
-					BuildArgs: []BuildArg{
-						{
-							Name:  "BUILD_STATE",
-							Value: deployment.GetEnvironment(),
+			variants := cmg.buildVariants(func(platform Platform) *Container {
+				return dag.Container(ContainerOpts{Platform: platform}).
+					Build(source, ContainerBuildOpts{
+						Dockerfile: file,
+						BuildArgs: []BuildArg{
+							{
+								Name:  "BUILD_STATE",
+								Value: deployment.GetEnvironment(),
+							},
 						},
-					},
-				}).
-				WithRegistryAuth("docker.io", user, dag.SetSecret("docker-pass", password)).
-				Publish(ctx, fmt.Sprintf(
-					"%s/%s:%s",
+					})
+			})
+			report, err := cmg.publishReport(
+				ctx,
+				variants,
+				cmg.imageRef(
 					pload.DockerNamespace,
 					allImages[idx],
 					pload.DockerImageTag,
-				))
+				),
+				user,
+				password,
+			)
 			if err != nil {
-				return fmt.Errorf(
-					"error in publishing docker container %s",
-					err,
-				)
+				return err
 			}
+			reports[idx] = report
 			return nil
 		})
 	}
-	return grp.Wait()
+	if err := grp.Wait(); err != nil {
+		return "", err
+	}
+	return strings.Join(reports, "\n"), nil
 }
 
 // publishFromRepoWithDeploymentIDCommon is a common method for publishing container images
@@ -349,15 +394,20 @@ func (cmg *ContainerImage) publishFromRepoWithDeploymentIDCommon(
 	password string,
 	deploymentID string,
 	token string,
-	buildFunc func(source *Directory, deployment *github.Deployment, pload Payload) *Container,
-) error {
+	buildFunc func(
+		platform Platform,
+		source *Directory,
+		deployment *github.Deployment,
+		pload Payload,
+	) *Container,
+) (string, error) {
 	owner, repo, err := parseOwnerRepo(cmg.Repository)
 	if err != nil {
-		return err
+		return "", err
 	}
 	depId, err := strconv.ParseInt(deploymentID, 10, 64)
 	if err != nil {
-		return fmt.Errorf("error in converting string to int64 %s", err)
+		return "", fmt.Errorf("error in converting string to int64 %s", err)
 	}
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
@@ -370,17 +420,17 @@ func (cmg *ContainerImage) publishFromRepoWithDeploymentIDCommon(
 		depId,
 	)
 	if err != nil {
-		return fmt.Errorf(
+		return "", fmt.Errorf(
 			"error in getting deployment information: %s",
 			err,
 		)
 	}
 	var pload Payload
 	if err := json.Unmarshal(deployment.Payload, &pload); err != nil {
-		return fmt.Errorf("error in decoding payload %s", err)
+		return "", fmt.Errorf("error in decoding payload %s", err)
 	}
 	if pload.Repository != cmg.Repository {
-		return fmt.Errorf(
+		return "", fmt.Errorf(
 			"payload repo %s and given repo %s does not match",
 			pload.Repository,
 			cmg.Repository,
@@ -391,24 +441,19 @@ func (cmg *ContainerImage) publishFromRepoWithDeploymentIDCommon(
 		WithRepository(fmt.Sprintf("%s/%s", githubURL, pload.Repository)).
 		Checkout()
 
-	container := buildFunc(source, deployment, pload)
+	variants := cmg.buildVariants(func(platform Platform) *Container {
+		return buildFunc(platform, source, deployment, pload)
+	})
 
-	_, err = container.
-		WithRegistryAuth(
-			"docker.io",
-			user,
-			dag.SetSecret("docker-pass", password),
-		).Publish(
+	return cmg.publishReport(
 		ctx,
-		fmt.Sprintf(
-			"%s/%s:%s",
+		variants,
+		cmg.imageRef(
 			pload.DockerNamespace,
 			pload.DockerImage,
 			pload.DockerImageTag,
 		),
+		user,
+		password,
 	)
-	if err != nil {
-		return fmt.Errorf("error in publishing docker container %s", err)
-	}
-	return nil
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// manifestIndex is the part of an OCI image index listing the manifest of
+// every platform.
+type manifestIndex struct {
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// WithPlatforms sets the platforms the images are built for, for example
+// linux/amd64 and linux/arm64. More than one platform publishes a multi-arch
+// manifest list.
+func (cmg *ContainerImage) WithPlatforms(
+	ctx context.Context,
+	// platforms in the os/arch[/variant] form
+	platforms []string,
+) *ContainerImage {
+	cmg.Platforms = platforms
+	return cmg
+}
+
+// buildVariants builds one container per configured platform, or a single
+// container for the platform of the engine when none is configured.
+func (cmg *ContainerImage) buildVariants(
+	build func(platform Platform) *Container,
+) []*Container {
+	if len(cmg.Platforms) == 0 {
+		return []*Container{build("")}
+	}
+	variants := make([]*Container, 0, len(cmg.Platforms))
+	for _, platform := range cmg.Platforms {
+		variants = append(variants, build(Platform(platform)))
+	}
+	return variants
+}
+
+// publishVariants publishes the platform variants under a single reference
+// and returns the published reference with its digest.
+func (cmg *ContainerImage) publishVariants(
+	ctx context.Context,
+	variants []*Container,
+	ref, user, password string,
+) (string, error) {
+	if len(variants) == 1 {
+		return cmg.withRegistryAuth(variants[0], user, password).
+			Publish(ctx, ref)
+	}
+	return cmg.withRegistryAuth(dag.Container(), user, password).
+		Publish(ctx, ref, ContainerPublishOpts{PlatformVariants: variants})
+}
+
+// publishReport publishes the platform variants and reports the published
+// reference followed by the digest of every platform of the manifest list.
+func (cmg *ContainerImage) publishReport(
+	ctx context.Context,
+	variants []*Container,
+	ref, user, password string,
+) (string, error) {
+	published, err := cmg.publishVariants(ctx, variants, ref, user, password)
+	if err != nil {
+		return "", fmt.Errorf("error in publishing docker container %s", err)
+	}
+	if len(variants) == 1 {
+		return published, nil
+	}
+	digests, err := cmg.platformDigests(ctx, published, user, password)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\n%s", published, digests), nil
+}
+
+// platformDigests fetches the manifest list of a published image with crane
+// and lists the digest of every platform.
+func (cmg *ContainerImage) platformDigests(
+	ctx context.Context,
+	ref, user, password string,
+) (string, error) {
+	ctr := craneContainer().
+		WithEnvVariable("REGISTRY", cmg.registryAddress())
+	script := `crane manifest "$1"`
+	if registryUser, secret := cmg.registryCredentials(user, password); secret != nil {
+		ctr = ctr.
+			WithEnvVariable("REGISTRY_USER", registryUser).
+			WithSecretVariable("REGISTRY_PASSWORD", secret)
+		script = `echo "$REGISTRY_PASSWORD" | crane auth login "$REGISTRY" -u "$REGISTRY_USER" --password-stdin > /dev/null && ` + script
+	}
+	out, err := ctr.
+		WithExec([]string{"sh", "-c", script, "--", ref}).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in fetching manifest list of %s %s", ref, err)
+	}
+	var index manifestIndex
+	if err := json.Unmarshal([]byte(out), &index); err != nil {
+		return "", fmt.Errorf("error in decoding manifest list of %s %s", ref, err)
+	}
+	var digests strings.Builder
+	for _, manifest := range index.Manifests {
+		platform := manifest.Platform
+		// attestation manifests are stored with an unknown platform
+		if platform.OS == "unknown" || len(platform.OS) == 0 {
+			continue
+		}
+		name := fmt.Sprintf("%s/%s", platform.OS, platform.Architecture)
+		if len(platform.Variant) > 0 {
+			name = fmt.Sprintf("%s/%s", name, platform.Variant)
+		}
+		fmt.Fprintf(&digests, "%s %s\n", name, manifest.Digest)
+	}
+	return digests.String(), nil
+}
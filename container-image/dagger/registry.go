@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHub          = "docker.io"
+	craneVersion       = "v0.20.2"
+	localRegistryImage = "registry:2"
+	localRegistryAlias = "registry"
+	localRegistryPort  = 5000
+)
+
+// WithRegistry sets the registry the images are published to together with
+// its credentials, for example ghcr.io or us-docker.pkg.dev
+func (cmg *ContainerImage) WithRegistry(
+	ctx context.Context,
+	// address of the registry
+	// +default="docker.io"
+	address string,
+	// user name of the registry
+	user string,
+	// password of the registry, use an api token
+	password *Secret,
+) *ContainerImage {
+	cmg.Registry = address
+	cmg.RegistryUser = user
+	cmg.RegistryPassword = password
+	return cmg
+}
+
+// PublishToLocalRegistry builds the image and pushes it to a throwaway
+// registry:2 service, returning the pushed reference with its digest. It
+// exercises publishing without any external registry or credentials.
+func (cmg *ContainerImage) PublishToLocalRegistry(
+	ctx context.Context,
+) (string, error) {
+	cont, err := cmg.GenerateImageTag(ctx)
+	if err != nil {
+		return "", err
+	}
+	registry := dag.Container().
+		From(localRegistryImage).
+		WithExposedPort(localRegistryPort).
+		AsService()
+	ref := fmt.Sprintf(
+		"%s:%d/%s",
+		localRegistryAlias,
+		localRegistryPort,
+		imagePath(cmg.Namespace, cmg.Image, cmg.DockerImageTag),
+	)
+	out, err := craneContainer().
+		WithServiceBinding(localRegistryAlias, registry).
+		WithMountedFile("/image.tar", cont.AsTarball()).
+		WithExec([]string{"crane", "push", "--insecure", "/image.tar", ref}).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in pushing to local registry %s", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// registryAddress returns the configured registry, Docker Hub by default.
+func (cmg *ContainerImage) registryAddress() string {
+	if len(cmg.Registry) == 0 {
+		return dockerHub
+	}
+	return cmg.Registry
+}
+
+// imageRef returns the full reference of an image in the configured
+// registry. Docker Hub references keep their short form.
+func (cmg *ContainerImage) imageRef(namespace, image, tag string) string {
+	if cmg.registryAddress() == dockerHub {
+		return imagePath(namespace, image, tag)
+	}
+	return fmt.Sprintf(
+		"%s/%s",
+		strings.TrimSuffix(cmg.Registry, "/"),
+		imagePath(namespace, image, tag),
+	)
+}
+
+// withRegistryAuth authenticates the container against the configured
+// registry. The credentials given to WithRegistry take precedence over the
+// user and password passed to the publish functions.
+func (cmg *ContainerImage) withRegistryAuth(
+	ctr *Container,
+	user, password string,
+) *Container {
+	registryUser, secret := cmg.registryCredentials(user, password)
+	if secret == nil {
+		return ctr
+	}
+	return ctr.WithRegistryAuth(cmg.registryAddress(), registryUser, secret)
+}
+
+// registryCredentials returns the user and password of the configured
+// registry, the password is nil when no credentials are available.
+func (cmg *ContainerImage) registryCredentials(
+	user, password string,
+) (string, *Secret) {
+	if cmg.RegistryPassword != nil {
+		return cmg.RegistryUser, cmg.RegistryPassword
+	}
+	if len(password) > 0 {
+		return user, dag.SetSecret("docker-pass", password)
+	}
+	return "", nil
+}
+
+// craneContainer returns a container with crane installed.
+func craneContainer() *Container {
+	return dag.Container().
+		From("golang:1.22-alpine").
+		WithExec([]string{
+			"go", "install",
+			fmt.Sprintf(
+				"github.com/google/go-containerregistry/cmd/crane@%s",
+				craneVersion,
+			),
+		})
+}
+
+// imagePath joins the namespace, image name and tag of an image reference.
+func imagePath(namespace, image, tag string) string {
+	if len(namespace) == 0 {
+		return fmt.Sprintf("%s:%s", image, tag)
+	}
+	return fmt.Sprintf("%s/%s:%s", namespace, image, tag)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// changedFilesScript lists the files changed since the merge base of the
+// given reference, including uncommitted changes.
+const changedFilesScript = `git -c safe.directory='*' diff --name-only \
+  "$(git -c safe.directory='*' merge-base "$1" HEAD)"`
+
+// listedPackage is the subset of the go list -json output needed to find
+// the reverse dependencies of a package.
+type listedPackage struct {
+	ImportPath   string
+	Dir          string
+	Deps         []string
+	TestImports  []string
+	XTestImports []string
+	Module       *struct {
+		Main bool
+	}
+}
+
+// TestAffected tests only the packages affected by the changes since the
+// given git reference, that is the changed packages and every package
+// depending on them. The full suite runs when go.mod or go.sum changed.
+func (gom *Golang) TestAffected(
+	ctx context.Context,
+	// The source directory to test including its git history, Required.
+	src *Directory,
+	// The git reference the changes are computed against
+	baseRef string,
+	// An optional slice of strings representing additional go test flags
+	// +optional
+	args []string,
+) (string, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	out, err := ctr.WithExec([]string{"sh", "-c", changedFilesScript, "sh", baseRef}).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in listing changed files %w", err)
+	}
+	changed := strings.Fields(out)
+	for _, file := range changed {
+		if name := path.Base(file); name == "go.mod" || name == "go.sum" {
+			return gom.runTests(ctx, ctr, args)
+		}
+	}
+	listing, err := ctr.WithExec([]string{"go", "list", "-deps", "-json", "./..."}).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in listing packages %w", err)
+	}
+	pkgs, err := parsePackageList(listing)
+	if err != nil {
+		return "", err
+	}
+	affected := affectedPackages(pkgs, changed)
+	if len(affected) == 0 {
+		return "no packages are affected by the changes", nil
+	}
+	testArgs := append([]string{}, args...)
+	for _, pkg := range affected {
+		rel, err := filepath.Rel(PROJ_MOUNT, pkg.Dir)
+		if err != nil {
+			return "", fmt.Errorf("error in resolving package dir %w", err)
+		}
+		testArgs = append(testArgs, "./"+rel)
+	}
+	return gom.runTests(ctx, ctr, testArgs)
+}
+
+// parsePackageList decodes the stream of go list -json objects, keeping
+// the packages of the main module.
+func parsePackageList(listing string) ([]*listedPackage, error) {
+	var pkgs []*listedPackage
+	dec := json.NewDecoder(strings.NewReader(listing))
+	for {
+		pkg := &listedPackage{}
+		err := dec.Decode(pkg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error in decoding package list %w", err)
+		}
+		if pkg.Module != nil && pkg.Module.Main {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs, nil
+}
+
+// affectedPackages maps the changed files to their packages and expands
+// them to every package importing them, directly, transitively or from its
+// tests.
+func affectedPackages(pkgs []*listedPackage, changed []string) []*listedPackage {
+	byDir := make(map[string]*listedPackage, len(pkgs))
+	for _, pkg := range pkgs {
+		byDir[pkg.Dir] = pkg
+	}
+	seeds := make(map[string]bool)
+	for _, file := range changed {
+		// files outside of a package directory, like testdata, belong to
+		// the closest package above them
+		for dir := path.Join(PROJ_MOUNT, path.Dir(file)); ; dir = path.Dir(dir) {
+			if pkg, ok := byDir[dir]; ok {
+				seeds[pkg.ImportPath] = true
+				break
+			}
+			if dir == PROJ_MOUNT || dir == "/" {
+				break
+			}
+		}
+	}
+	affected := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if seeds[pkg.ImportPath] || importsAny(pkg.Deps, seeds) {
+			affected[pkg.ImportPath] = true
+		}
+	}
+	// test imports are not part of Deps, they are followed until nothing
+	// new is found
+	for grown := true; grown; {
+		grown = false
+		for _, pkg := range pkgs {
+			if affected[pkg.ImportPath] {
+				continue
+			}
+			if importsAny(pkg.TestImports, affected) ||
+				importsAny(pkg.XTestImports, affected) {
+				affected[pkg.ImportPath] = true
+				grown = true
+			}
+		}
+	}
+	var result []*listedPackage
+	for _, pkg := range pkgs {
+		if affected[pkg.ImportPath] {
+			result = append(result, pkg)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ImportPath < result[j].ImportPath
+	})
+	return result
+}
+
+func importsAny(imports []string, set map[string]bool) bool {
+	for _, imp := range imports {
+		if set[imp] {
+			return true
+		}
+	}
+	return false
+}
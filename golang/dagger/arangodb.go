@@ -14,32 +14,65 @@ func (gom *Golang) TestsWithArangoDB(
 	// +optional
 	args []string,
 ) (string, error) {
-	arangoService := dag.Container().
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	ctr, err = gom.withArangoDB(ctx, "", ctr)
+	if err != nil {
+		return "", err
+	}
+	return gom.runTests(ctx, ctr, args)
+}
+
+// withArangoDB binds an ArangoDB service to the container and exports its
+// connection details. The instance distinguishes otherwise identical
+// services that must not be shared between containers.
+func (gom *Golang) withArangoDB(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	if gom.ArangoMode == arangoCluster {
+		return gom.withArangoCluster(ctx, instance, ctr)
+	}
+	arangoService := serviceInstance(instance, dag.Container().
 		From(fmt.Sprintf("%s:%s", "arangodb", gom.ArangoVersion)).
 		WithEnvVariable("ARANGO_ROOT_PASSWORD", gom.ArangoPassword).
-		WithExposedPort(gom.ArangoPort).
-		AsService()
+		WithExposedPort(gom.ArangoPort),
+	).AsService()
 
 	arangoHost, err := arangoService.Hostname(ctx)
 	if err != nil {
-		return arangoHost, fmt.Errorf(
+		return nil, fmt.Errorf(
 			"error in retrieving arangodb host %w",
 			err,
 		)
 	}
+	// keep the service running so that the provisioned data is still
+	// around when the tests start
+	arangoService, err = arangoService.Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in starting arangodb service %w", err)
+	}
+	if err := gom.setupArangoDB(ctx, arangoService); err != nil {
+		return nil, err
+	}
+
+	return gom.bindArango(arangoService, arangoHost, ctr), nil
+}
 
-	return gom.PrepareTestContainer(ctx).
-		WithServiceBinding("arango", arangoService).
-		WithEnvVariable("ARANGO_HOST", arangoHost).
+// bindArango binds the ArangoDB service that receives the client requests
+// to the container and exports its connection details.
+func (gom *Golang) bindArango(
+	svc *Service,
+	host string,
+	ctr *Container,
+) *Container {
+	return ctr.WithServiceBinding("arango", svc).
+		WithEnvVariable("ARANGO_HOST", host).
 		WithEnvVariable("ARANGO_PASS", gom.ArangoPassword).
-		WithEnvVariable("ARANGO_USER", "root").
-		WithMountedDirectory(PROJ_MOUNT, src).
-		WithWorkdir(PROJ_MOUNT).
-		WithExec([]string{"go", "mod", "download"}).
-		WithExec(append([]string{
-			"gotestsum", "--format", gom.GotestSumFormatter, "--",
-		}, args...)).
-		Stdout(ctx)
+		WithEnvVariable("ARANGO_USER", "root")
 }
 
 // TestsWithArangoDBFromGithub fetches a GitHub repository and runs Go tests with ArangoDB.
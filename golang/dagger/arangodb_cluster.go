@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	arangoCluster    = "cluster"
+	arangoAgentPort  = 8531
+	arangoDBPort     = 8530
+	arangoJWTSecret  = "dagger-arangodb-cluster"
+	arangoRoleAgent  = "AGENT"
+	arangoRoleDB     = "DBSERVER"
+	arangoRoleCoord  = "COORDINATOR"
+	arangoAgentAlias = "arango-agent"
+	// arangoNodeScript starts an arangod cluster member announcing its
+	// container address to the rest of the cluster.
+	arangoNodeScript = `printf %s "$ARANGO_JWT_SECRET" > /tmp/jwt-secret
+addr="tcp://$(hostname -i | awk '{print $1}'):$ARANGO_NODE_PORT"
+if [ "$ARANGO_ROLE" = AGENT ]; then
+  set -- --agency.activate true --agency.size 1 --agency.supervision true \
+    --agency.my-address "$addr" --agency.endpoint "$addr"
+else
+  set -- --cluster.my-role "$ARANGO_ROLE" --cluster.my-address "$addr" \
+    --cluster.agency-endpoint "$ARANGO_AGENCY"
+fi
+exec arangod --server.endpoint "tcp://0.0.0.0:$ARANGO_NODE_PORT" \
+  --server.jwt-secret-keyfile /tmp/jwt-secret \
+  --database.directory /var/lib/arangodb3 "$@"`
+	// arangoRootScript waits for the coordinator and sets the root password
+	// of the freshly bootstrapped cluster.
+	arangoRootScript = `for i in $(seq 1 90); do
+  arangosh --server.endpoint "tcp://arango:$ARANGO_PORT" --server.password "" \
+    --javascript.execute-string \
+    'require("@arangodb/users").update("root", require("internal").env.ARANGO_ROOT_PASSWORD)' \
+    && exit 0
+  sleep 2
+done
+echo "arangodb cluster is not ready after 180 seconds" >&2
+exit 1`
+)
+
+// WithArangoCluster runs ArangoDB as a cluster made of an agency, DB
+// servers and coordinators instead of a single server.
+func (gom *Golang) WithArangoCluster(
+	// Number of DB servers
+	// +optional
+	// +default=2
+	dbServers int,
+	// Number of coordinators
+	// +optional
+	// +default=1
+	coordinators int,
+) (*Golang, error) {
+	if dbServers < 1 || coordinators < 1 {
+		return gom, fmt.Errorf(
+			"a cluster needs at least one DB server and coordinator, got %d and %d",
+			dbServers, coordinators,
+		)
+	}
+	gom.ArangoMode = arangoCluster
+	gom.ArangoDBServers = dbServers
+	gom.ArangoCoordinators = coordinators
+	return gom, nil
+}
+
+// withArangoCluster starts the members of an ArangoDB cluster and binds its
+// coordinators to the container. ARANGO_HOST points at the first
+// coordinator.
+func (gom *Golang) withArangoCluster(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	agent, err := gom.startArangoNode(
+		ctx,
+		gom.arangoNode(instance, "agent", arangoRoleAgent, arangoAgentPort),
+	)
+	if err != nil {
+		return nil, err
+	}
+	agentHost, err := agent.Hostname(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in retrieving arangodb agent host %w", err)
+	}
+	agency := fmt.Sprintf("tcp://%s:%d", agentHost, arangoAgentPort)
+	member := func(node, role string, port int) (*Service, error) {
+		return gom.startArangoNode(ctx, gom.arangoNode(instance, node, role, port).
+			WithServiceBinding(arangoAgentAlias, agent).
+			WithEnvVariable("ARANGO_AGENCY", agency),
+		)
+	}
+	for idx := 0; idx < gom.ArangoDBServers; idx++ {
+		_, err := member(
+			fmt.Sprintf("dbserver-%d", idx),
+			arangoRoleDB,
+			arangoDBPort,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var coordinators []*Service
+	for idx := 0; idx < gom.ArangoCoordinators; idx++ {
+		coordinator, err := member(
+			fmt.Sprintf("coordinator-%d", idx),
+			arangoRoleCoord,
+			gom.ArangoPort,
+		)
+		if err != nil {
+			return nil, err
+		}
+		coordinators = append(coordinators, coordinator)
+	}
+	image := fmt.Sprintf("%s:%s", "arangodb", gom.ArangoVersion)
+	_, err = uncached(dag.Container().From(image)).
+		WithServiceBinding("arango", coordinators[0]).
+		WithEnvVariable("ARANGO_PORT", fmt.Sprintf("%d", gom.ArangoPort)).
+		WithEnvVariable("ARANGO_ROOT_PASSWORD", gom.ArangoPassword).
+		WithExec(
+			[]string{"sh", "-c", arangoRootScript},
+			ContainerWithExecOpts{SkipEntrypoint: true},
+		).
+		Sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in bootstrapping arangodb cluster %w", err)
+	}
+	if err := gom.setupArangoDB(ctx, coordinators[0]); err != nil {
+		return nil, err
+	}
+	host, err := coordinators[0].Hostname(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in retrieving arangodb coordinator host %w", err)
+	}
+	for idx, coordinator := range coordinators[1:] {
+		ctr = ctr.WithServiceBinding(
+			fmt.Sprintf("arango-coordinator-%d", idx+1),
+			coordinator,
+		)
+	}
+	return gom.bindArango(coordinators[0], host, ctr), nil
+}
+
+// arangoNode prepares a cluster member with the given role.
+func (gom *Golang) arangoNode(instance, node, role string, port int) *Container {
+	return serviceInstance(instance, dag.Container().
+		From(fmt.Sprintf("%s:%s", "arangodb", gom.ArangoVersion)).
+		WithEnvVariable("ARANGO_NODE", node).
+		WithEnvVariable("ARANGO_ROLE", role).
+		WithEnvVariable("ARANGO_NODE_PORT", fmt.Sprintf("%d", port)).
+		WithEnvVariable("ARANGO_JWT_SECRET", arangoJWTSecret).
+		WithExposedPort(port),
+	)
+}
+
+// startArangoNode starts a cluster member and keeps it running. The members
+// talk to each other without service bindings, so Dagger would not start
+// them on its own.
+func (gom *Golang) startArangoNode(
+	ctx context.Context,
+	ctr *Container,
+) (*Service, error) {
+	svc, err := ctr.WithExec(
+		[]string{"sh", "-c", arangoNodeScript},
+		ContainerWithExecOpts{SkipEntrypoint: true},
+	).AsService().Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in starting arangodb cluster member %w", err)
+	}
+	return svc, nil
+}
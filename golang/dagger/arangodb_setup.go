@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	arangoFixtureMount = "/fixtures"
+	// arangoReadyScript polls the version endpoint until the server answers.
+	arangoReadyScript = `for i in $(seq 1 60); do
+  wget -q -O /dev/null \
+    --header "Authorization: Basic $ARANGO_AUTH" \
+    "http://arango:$ARANGO_PORT/_api/version" && exit 0
+  sleep 2
+done
+echo "arangodb is not ready after 120 seconds" >&2
+exit 1`
+	// arangoImportScript imports every json or jsonl file of the fixture
+	// directory into the collection named after the file.
+	arangoImportScript = `for f in /fixtures/*.json /fixtures/*.jsonl; do
+  [ -e "$f" ] || continue
+  name=$(basename "$f")
+  type=json
+  case "$f" in *.jsonl) type=jsonl ;; esac
+  arangoimport \
+    --server.endpoint "tcp://arango:$ARANGO_PORT" \
+    --server.password "$ARANGO_ROOT_PASSWORD" \
+    --server.database "$ARANGO_FIXTURE_DB" \
+    --create-database true \
+    --create-collection true \
+    --collection "${name%.*}" \
+    --type "$type" \
+    --file "$f" || exit 1
+done`
+)
+
+// ArangoUser is an ArangoDB user created before the tests run.
+type ArangoUser struct {
+	Name     string
+	Password string
+}
+
+// WithArangoDatabases sets the databases created before the tests run.
+func (gom *Golang) WithArangoDatabases(
+	// Names of the databases to create
+	names []string,
+) *Golang {
+	gom.ArangoDatabases = names
+	return gom
+}
+
+// WithArangoUser adds a user that is created before the tests run and
+// granted read-write access to the provisioned databases.
+func (gom *Golang) WithArangoUser(
+	// Name of the user
+	name string,
+	// Password of the user
+	password string,
+) *Golang {
+	gom.ArangoUsers = append(
+		gom.ArangoUsers,
+		&ArangoUser{Name: name, Password: password},
+	)
+	return gom
+}
+
+// WithArangoFixtures sets the fixtures loaded into ArangoDB before the tests
+// run.
+func (gom *Golang) WithArangoFixtures(
+	// Directory with the fixtures. For the import method it contains one
+	// json or jsonl file per collection, for the restore method an
+	// arangodump output.
+	fixtures *Directory,
+	// The database that receives the fixtures
+	// +optional
+	// +default="_system"
+	database string,
+	// Either import to use arangoimport or restore to use arangorestore
+	// +optional
+	// +default="import"
+	method string,
+) (*Golang, error) {
+	if method != "import" && method != "restore" {
+		return gom, fmt.Errorf("unsupported fixture method %s", method)
+	}
+	gom.ArangoFixtures = fixtures
+	gom.ArangoFixtureDatabase = database
+	gom.ArangoFixtureMethod = method
+	return gom, nil
+}
+
+// setupArangoDB waits for the ArangoDB service to accept requests, then
+// creates the configured databases and users and loads the fixtures.
+func (gom *Golang) setupArangoDB(ctx context.Context, svc *Service) error {
+	auth := base64.StdEncoding.EncodeToString(
+		[]byte(fmt.Sprintf("root:%s", gom.ArangoPassword)),
+	)
+	image := fmt.Sprintf("%s:%s", "arangodb", gom.ArangoVersion)
+	// the service is fresh for every run, so the setup must never be
+	// served from cache
+	setup := uncached(dag.Container().From(image)).
+		WithServiceBinding("arango", svc).
+		WithEnvVariable("ARANGO_PORT", fmt.Sprintf("%d", gom.ArangoPort)).
+		WithEnvVariable("ARANGO_ROOT_PASSWORD", gom.ArangoPassword).
+		WithEnvVariable("ARANGO_AUTH", auth).
+		WithExec(
+			[]string{"sh", "-c", arangoReadyScript},
+			ContainerWithExecOpts{SkipEntrypoint: true},
+		)
+	if len(gom.ArangoDatabases) > 0 || len(gom.ArangoUsers) > 0 {
+		script, err := gom.arangoProvisionScript()
+		if err != nil {
+			return err
+		}
+		setup = setup.WithExec(
+			gom.arangosh(script),
+			ContainerWithExecOpts{SkipEntrypoint: true},
+		)
+	}
+	if gom.ArangoFixtures != nil {
+		setup = gom.arangoSeed(setup)
+	}
+	if _, err := setup.Sync(ctx); err != nil {
+		return fmt.Errorf("error in setting up arangodb %w", err)
+	}
+	return nil
+}
+
+// arangoSeed loads the fixtures with either arangoimport or arangorestore.
+func (gom *Golang) arangoSeed(ctr *Container) *Container {
+	ctr = ctr.WithMountedDirectory(arangoFixtureMount, gom.ArangoFixtures).
+		WithEnvVariable("ARANGO_FIXTURE_DB", gom.ArangoFixtureDatabase)
+	if gom.ArangoFixtureMethod == "restore" {
+		return ctr.WithExec([]string{
+			"arangorestore",
+			"--server.endpoint", fmt.Sprintf("tcp://arango:%d", gom.ArangoPort),
+			"--server.password", gom.ArangoPassword,
+			"--server.database", gom.ArangoFixtureDatabase,
+			"--create-database", "true",
+			"--input-directory", arangoFixtureMount,
+		}, ContainerWithExecOpts{SkipEntrypoint: true})
+	}
+	return ctr.WithExec(
+		[]string{"sh", "-c", arangoImportScript},
+		ContainerWithExecOpts{SkipEntrypoint: true},
+	)
+}
+
+// arangosh builds the command executing the given javascript as root.
+func (gom *Golang) arangosh(script string) []string {
+	return []string{
+		"arangosh",
+		"--server.endpoint", fmt.Sprintf("tcp://arango:%d", gom.ArangoPort),
+		"--server.password", gom.ArangoPassword,
+		"--javascript.execute-string", script,
+	}
+}
+
+// arangoProvisionScript generates the javascript creating the configured
+// databases and users. Existing ones are left untouched.
+func (gom *Golang) arangoProvisionScript() (string, error) {
+	databases, err := json.Marshal(gom.ArangoDatabases)
+	if err != nil {
+		return "", fmt.Errorf("error in encoding arangodb databases %w", err)
+	}
+	users, err := json.Marshal(gom.ArangoUsers)
+	if err != nil {
+		return "", fmt.Errorf("error in encoding arangodb users %w", err)
+	}
+	return fmt.Sprintf(`const users = require("@arangodb/users");
+const databases = %s;
+databases.forEach((name) => {
+  if (!db._databases().includes(name)) {
+    db._createDatabase(name);
+  }
+});
+%s.forEach((user) => {
+  if (!users.exists(user.Name)) {
+    users.save(user.Name, user.Password);
+  }
+  databases.forEach((name) => users.grantDatabase(user.Name, name, "rw"));
+});`, databases, users), nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	benchLineRe = regexp.MustCompile(
+		`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+([0-9.]+)\s+ns/op`,
+	)
+	benchPkgRe = regexp.MustCompile(`^pkg:\s+(\S+)`)
+)
+
+// BenchCompare runs Go benchmarks on two git references of a GitHub
+// repository and returns a benchstat comparison table.
+func (gom *Golang) BenchCompare(
+	ctx context.Context,
+	// The GitHub repository name (e.g., "username/repo")
+	repository string,
+	// The git reference (branch, tag, or commit) used as the baseline
+	baseRef string,
+	// The git reference (branch, tag, or commit) compared against the baseline
+	headRef string,
+	// Regular expression selecting the benchmarks to run
+	// +optional
+	// +default="."
+	pattern string,
+	// Number of times each benchmark is run
+	// +optional
+	// +default=6
+	count int,
+	// Maximum allowed slowdown of any benchmark in percent, zero disables the check
+	// +optional
+	// +default=0
+	threshold int,
+) (string, error) {
+	benchCmd := []string{
+		"go", "test",
+		"-run", "^$",
+		"-bench", pattern,
+		"-benchmem",
+		"-count", strconv.Itoa(count),
+		"./...",
+	}
+	head := githubCheckout(repository, headRef)
+	// both refs run on the toolchain of head, so that only the code differs
+	version, err := gom.golangVersion(ctx, head)
+	if err != nil {
+		return "", err
+	}
+	baseOut, err := gom.runBenchmarks(
+		ctx,
+		version,
+		githubCheckout(repository, baseRef),
+		benchCmd,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error in running benchmarks on %s %w", baseRef, err)
+	}
+	headOut, err := gom.runBenchmarks(ctx, version, head, benchCmd)
+	if err != nil {
+		return "", fmt.Errorf("error in running benchmarks on %s %w", headRef, err)
+	}
+	table, err := gom.goBase(version).
+		WithExec([]string{"go", "install", "golang.org/x/perf/cmd/benchstat@latest"}).
+		WithDirectory("/bench", dag.Directory().
+			WithNewFile("base", baseOut).
+			WithNewFile("head", headOut),
+		).
+		WithWorkdir("/bench").
+		WithExec([]string{"benchstat", "base", "head"}).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in running benchstat %w", err)
+	}
+	if threshold <= 0 {
+		return table, nil
+	}
+	regressions := benchRegressions(
+		benchMeans(baseOut),
+		benchMeans(headOut),
+		float64(threshold),
+	)
+	if len(regressions) > 0 {
+		return table, fmt.Errorf(
+			"benchmarks regressed by more than %d%%:\n%s\n\n%s",
+			threshold,
+			strings.Join(regressions, "\n"),
+			table,
+		)
+	}
+	return table, nil
+}
+
+// runBenchmarks executes the benchmark command on the given source with the
+// given version of Go and returns the raw benchmark output.
+func (gom *Golang) runBenchmarks(
+	ctx context.Context,
+	version string,
+	src *Directory,
+	cmd []string,
+) (string, error) {
+	ctr, err := gom.versionedTestContainer(ctx, version, src)
+	if err != nil {
+		return "", err
+	}
+	return ctr.WithExec(cmd).Stdout(ctx)
+}
+
+// benchMeans parses go test benchmark output and returns the mean ns/op of
+// every benchmark keyed by its package and name.
+func benchMeans(output string) map[string]float64 {
+	var pkg string
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if match := benchPkgRe.FindStringSubmatch(line); match != nil {
+			pkg = match[1]
+			continue
+		}
+		match := benchLineRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		name := fmt.Sprintf("%s.%s", pkg, match[1])
+		sums[name] += value
+		counts[name]++
+	}
+	means := make(map[string]float64, len(sums))
+	for name, sum := range sums {
+		means[name] = sum / float64(counts[name])
+	}
+	return means
+}
+
+// benchRegressions lists the benchmarks whose mean time grew by more than
+// threshold percent between base and head.
+func benchRegressions(base, head map[string]float64, threshold float64) []string {
+	var regressions []string
+	for name, headMean := range head {
+		baseMean, ok := base[name]
+		if !ok || baseMean == 0 {
+			continue
+		}
+		delta := (headMean - baseMean) / baseMean * 100
+		if delta > threshold {
+			regressions = append(regressions, fmt.Sprintf(
+				"%s: %.2f ns/op -> %.2f ns/op (+%.2f%%)",
+				name, baseMean, headMean, delta,
+			))
+		}
+	}
+	sort.Strings(regressions)
+	return regressions
+}
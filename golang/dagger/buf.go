@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+const (
+	protocGenGoVersion     = "v1.34.2"
+	protocGenGoGrpcVersion = "v1.5.1"
+	bufGenConfig           = "buf.gen.yaml"
+	bufConfig              = "buf.yaml"
+	bufAgainstMount        = "/against"
+	defaultBufGenTemplate  = "/etc/buf/buf.gen.yaml"
+	// teamBufGenTemplate generates go and grpc stubs next to the proto files
+	// with the locally installed plugins.
+	teamBufGenTemplate = `version: v2
+plugins:
+  - local: protoc-gen-go
+    out: .
+    opt: paths=source_relative
+  - local: protoc-gen-go-grpc
+    out: .
+    opt: paths=source_relative
+`
+	// wireBreakingConfig only reports changes that break the wire format,
+	// used when the source has no buf.yaml of its own.
+	wireBreakingConfig = `{"version":"v2","breaking":{"use":["WIRE"]}}`
+)
+
+// BufGenerate generates the protobuf and gRPC code of the source with buf and
+// returns the generated files. The buf.gen.yaml of the source is used when
+// present, otherwise go and go-grpc stubs are generated next to the proto
+// files.
+func (gom *Golang) BufGenerate(
+	ctx context.Context,
+	// The source directory containing the proto files, Required.
+	src *Directory,
+) (*Directory, error) {
+	ctr, err := gom.bufContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := src.Entries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in listing source entries %w", err)
+	}
+	cmd := []string{"buf", "generate"}
+	if !slices.Contains(entries, bufGenConfig) {
+		ctr = ctr.WithNewFile(
+			defaultBufGenTemplate,
+			ContainerWithNewFileOpts{Contents: teamBufGenTemplate},
+		)
+		cmd = append(cmd, "--template", defaultBufGenTemplate)
+	}
+	generated, err := ctr.WithExec(cmd).Directory(PROJ_MOUNT).Sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in running buf generate %w", err)
+	}
+	return src.Diff(generated), nil
+}
+
+// BufBreaking compares the proto files of the source against a git reference
+// of a GitHub repository and fails on wire incompatible changes. The
+// breaking rules of the buf.yaml of the source take precedence.
+func (gom *Golang) BufBreaking(
+	ctx context.Context,
+	// The source directory containing the proto files, Required.
+	src *Directory,
+	// The GitHub repository name (e.g., "username/repo")
+	repository string,
+	// The git reference (branch, tag, or commit) to compare against
+	againstRef string,
+) (string, error) {
+	ctr, err := gom.bufContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	entries, err := src.Entries(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in listing source entries %w", err)
+	}
+	cmd := []string{"buf", "breaking", "--against", bufAgainstMount}
+	if !slices.Contains(entries, bufConfig) {
+		cmd = append(cmd, "--config", wireBreakingConfig)
+	}
+	output, code, err := execStatus(ctx, statusExec(cmd)(
+		ctr.WithMountedDirectory(
+			bufAgainstMount,
+			githubCheckout(repository, againstRef),
+		),
+	))
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return output, fmt.Errorf(
+			"breaking proto changes against %s:\n%s",
+			againstRef,
+			output,
+		)
+	}
+	return "no breaking changes detected", nil
+}
+
+// bufContainer returns the source container with buf and the pinned protoc
+// plugins installed.
+func (gom *Golang) bufContainer(
+	ctx context.Context,
+	src *Directory,
+) (*Container, error) {
+	ctr, err := gom.sourceContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return withBufPlugins(withBuf(ctr)), nil
+}
+
+// withBufPlugins installs the pinned protoc plugins used by buf generate.
+func withBufPlugins(ctr *Container) *Container {
+	return ctr.
+		WithExec([]string{
+			"go", "install",
+			fmt.Sprintf(
+				"google.golang.org/protobuf/cmd/protoc-gen-go@%s",
+				protocGenGoVersion,
+			),
+		}).
+		WithExec([]string{
+			"go", "install",
+			fmt.Sprintf(
+				"google.golang.org/grpc/cmd/protoc-gen-go-grpc@%s",
+				protocGenGoGrpcVersion,
+			),
+		})
+}
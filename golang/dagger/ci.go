@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const govulncheckVersion = "v1.1.3"
+
+// ciStage runs a single stage of the CI pipeline and returns its output
+// and exit code, an error is only returned when the stage could not run.
+type ciStage func(gom *Golang, ctx context.Context, src *Directory, cfg ciConfig) (string, int, error)
+
+// ciConfig holds the settings shared by the stages of the CI pipeline.
+type ciConfig struct {
+	LintVersion string
+	Buf         bool
+	Args        []string
+}
+
+// ciStages lists the stages of the CI pipeline by name.
+var ciStages = map[string]ciStage{
+	"lint":  (*Golang).ciLint,
+	"vet":   (*Golang).ciVet,
+	"test":  (*Golang).ciTest,
+	"drift": (*Golang).ciDrift,
+	"vuln":  (*Golang).ciVuln,
+}
+
+// CI runs the lint, vet, test, drift and vulnerability stages concurrently
+// and returns a single summary of their status and duration. It fails when
+// any required stage failed.
+func (gom *Golang) CI(
+	ctx context.Context,
+	// The source directory to check, Required.
+	src *Directory,
+	// Stages to run, any of lint, vet, test, drift and vuln
+	// +optional
+	// +default=["lint","vet","test","drift","vuln"]
+	stages []string,
+	// Stages whose failure fails the pipeline, defaults to every stage that
+	// is run
+	// +optional
+	required []string,
+	// Format of the summary, markdown or json
+	// +optional
+	// +default="markdown"
+	format string,
+	// An optional string specifying the version of golangci-lint to use
+	// +optional
+	// +default="v1.55.2-alpine"
+	lintVersion string,
+	// Whether the drift stage runs buf generate as well
+	// +optional
+	// +default=false
+	buf bool,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	if format != "markdown" && format != "json" {
+		return "", fmt.Errorf("unsupported summary format %s", format)
+	}
+	if len(required) == 0 {
+		required = stages
+	}
+	for _, name := range stages {
+		if _, ok := ciStages[name]; !ok {
+			return "", fmt.Errorf("unknown stage %s", name)
+		}
+	}
+	for _, name := range required {
+		if !slices.Contains(stages, name) {
+			return "", fmt.Errorf("required stage %s is not among the stages to run", name)
+		}
+	}
+	cfg := ciConfig{LintVersion: lintVersion, Buf: buf, Args: args}
+	results := make([]*stageResult, len(stages))
+	grp, gctx := errgroup.WithContext(ctx)
+	for idx, name := range stages {
+		result := &stageResult{
+			Module:   ".",
+			Stage:    name,
+			Required: slices.Contains(required, name),
+		}
+		results[idx] = result
+		stage := ciStages[name]
+		grp.Go(func() error {
+			start := time.Now()
+			output, code, err := stage(gom, gctx, src, cfg)
+			if err != nil {
+				return fmt.Errorf("error in running %s stage %w", result.Stage, err)
+			}
+			result.Output = output
+			result.Code = code
+			result.Duration = time.Since(start)
+			return nil
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return "", err
+	}
+	summary, err := stagesReport(results, format)
+	if err != nil {
+		return "", err
+	}
+	if failed := failedStages(results); len(failed) > 0 {
+		return summary, fmt.Errorf(
+			"required stages failed: %s\n%s",
+			strings.Join(failed, ", "),
+			summary,
+		)
+	}
+	return summary, nil
+}
+
+// ciLint runs golangci-lint on the source.
+func (gom *Golang) ciLint(
+	ctx context.Context,
+	src *Directory,
+	cfg ciConfig,
+) (string, int, error) {
+	ctr, cfgPath, err := gom.lintContainer(ctx, cfg.LintVersion, src, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	return execStatus(ctx, statusExec([]string{
+		"golangci-lint", "run", "-c", cfgPath, "./...",
+	})(ctr))
+}
+
+// ciVet runs go vet on the source.
+func (gom *Golang) ciVet(
+	ctx context.Context,
+	src *Directory,
+	_ ciConfig,
+) (string, int, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", 0, err
+	}
+	return execStatus(ctx, statusExec([]string{"go", "vet", "./..."})(ctr))
+}
+
+// ciTest runs the tests of the source with gotestsum, applying the rerun
+// policy of WithRerunFails.
+func (gom *Golang) ciTest(
+	ctx context.Context,
+	src *Directory,
+	cfg ciConfig,
+) (string, int, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", 0, err
+	}
+	run, err := gom.runTestSuite(ctx, ctr, withDefaultPackages(cfg.Args))
+	if err != nil {
+		return "", 0, err
+	}
+	return run.Output, run.Code, nil
+}
+
+// ciDrift regenerates the code of the source and fails when any file
+// changed or the regeneration itself failed.
+func (gom *Golang) ciDrift(
+	ctx context.Context,
+	src *Directory,
+	cfg ciConfig,
+) (string, int, error) {
+	ctr, err := gom.driftContainer(ctx, src, cfg.Buf)
+	if err != nil {
+		return "", 0, err
+	}
+	out, err := ctr.File(driftFiles).Contents(ctx)
+	if err != nil {
+		return fmt.Sprintf("error in regenerating code %s", err), 1, nil
+	}
+	if files := strings.TrimSpace(out); len(files) > 0 {
+		return fmt.Sprintf("drifted files:\n%s", files), 1, nil
+	}
+	return "no drift detected", 0, nil
+}
+
+// ciVuln scans the source and its dependencies with govulncheck.
+func (gom *Golang) ciVuln(
+	ctx context.Context,
+	src *Directory,
+	_ ciConfig,
+) (string, int, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", 0, err
+	}
+	return execStatus(ctx, statusExec([]string{"govulncheck", "./..."})(
+		ctr.WithExec([]string{
+			"go", "install",
+			fmt.Sprintf("golang.org/x/vuln/cmd/govulncheck@%s", govulncheckVersion),
+		}),
+	))
+}
@@ -0,0 +1,55 @@
+package main
+
+import "context"
+
+const (
+	goModCache   = "/cache/go/mod"
+	goBuildCache = "/cache/go/build"
+)
+
+// DebugTerminal opens an interactive shell in the prepared test container
+// with the source mounted, the module and build caches warm, dlv installed
+// and the selected services bound, for rerunning a failing test against the
+// same environment as the CI run.
+func (gom *Golang) DebugTerminal(
+	ctx context.Context,
+	// The source directory to debug, Required.
+	src *Directory,
+	// Services bound to the container, any of arangodb, redis, postgres,
+	// kubernetes, minio, gcs and nats
+	// +optional
+	services []string,
+) (*Terminal, error) {
+	ctr, err := gom.debugContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	ctr, err = gom.bindServices(ctx, "", services, ctr)
+	if err != nil {
+		return nil, err
+	}
+	return ctr.Terminal(ContainerTerminalOpts{Cmd: []string{"sh"}}), nil
+}
+
+// debugContainer returns the source container with persistent go caches,
+// dependencies downloaded, the test binaries compiled and dlv installed.
+// Packages that fail to compile do not prevent the terminal from opening.
+func (gom *Golang) debugContainer(
+	ctx context.Context,
+	src *Directory,
+) (*Container, error) {
+	ctr, err := gom.sourceContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return ctr.
+		WithMountedCache(goModCache, dag.CacheVolume("golang-mod-cache")).
+		WithMountedCache(goBuildCache, dag.CacheVolume("golang-build-cache")).
+		WithEnvVariable("GOMODCACHE", goModCache).
+		WithEnvVariable("GOCACHE", goBuildCache).
+		WithExec([]string{"go", "install", "github.com/go-delve/delve/cmd/dlv@latest"}).
+		WithExec([]string{"go", "mod", "download"}).
+		WithExec([]string{
+			"sh", "-c", "go test -count=1 -run '^$' ./... > /dev/null 2>&1 || true",
+		}), nil
+}
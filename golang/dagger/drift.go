@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	bufVersion = "v1.36.0"
+	driftFiles = "/tmp/drift/files"
+	driftPatch = "/tmp/drift/drift.patch"
+	// driftBaselineScript snapshots the source in a separate git directory,
+	// so that any git repository of the source itself is left alone.
+	driftBaselineScript = `set -e
+g() { git --git-dir=/tmp/drift.git --work-tree="$PWD" "$@"; }
+g init -q
+g add -A
+g -c user.name=drift -c user.email=drift@localhost commit -q --allow-empty -m baseline`
+	// driftScript regenerates everything and records the difference to the
+	// baseline snapshot.
+	driftScript = `set -e
+g() { git --git-dir=/tmp/drift.git --work-tree="$PWD" "$@"; }
+go mod tidy
+go generate ./...
+if [ "$DRIFT_BUF" = "true" ]; then
+  buf generate
+fi
+g add -A
+mkdir -p /tmp/drift
+g diff --cached --name-only > /tmp/drift/files
+g diff --cached --binary > /tmp/drift/drift.patch`
+)
+
+// CheckDrift verifies that go mod tidy, go generate and optionally buf
+// generate leave the source unchanged. It fails with the list of drifted
+// files.
+func (gom *Golang) CheckDrift(
+	ctx context.Context,
+	// The source directory to check, Required.
+	src *Directory,
+	// Whether to run buf generate as well
+	// +optional
+	// +default=false
+	buf bool,
+) (string, error) {
+	ctr, err := gom.driftContainer(ctx, src, buf)
+	if err != nil {
+		return "", err
+	}
+	out, err := ctr.File(driftFiles).Contents(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in checking drift %w", err)
+	}
+	files := strings.TrimSpace(out)
+	if len(files) > 0 {
+		return files, fmt.Errorf(
+			"generated code or go.mod/go.sum is out of date, drifted files:\n%s",
+			files,
+		)
+	}
+	return "no drift detected", nil
+}
+
+// DriftPatch returns the changes of go mod tidy, go generate and optionally
+// buf generate as a patch that can be applied with git apply.
+func (gom *Golang) DriftPatch(
+	ctx context.Context,
+	// The source directory to check, Required.
+	src *Directory,
+	// Whether to run buf generate as well
+	// +optional
+	// +default=false
+	buf bool,
+) (*File, error) {
+	ctr, err := gom.driftContainer(ctx, src, buf)
+	if err != nil {
+		return nil, err
+	}
+	return ctr.File(driftPatch), nil
+}
+
+// driftContainer regenerates the source and records the drift.
+func (gom *Golang) driftContainer(
+	ctx context.Context,
+	src *Directory,
+	buf bool,
+) (*Container, error) {
+	// the baseline is taken before any go command runs, go mod download
+	// would otherwise fill in a stale go.sum and hide its drift
+	ctr, err := gom.sourceContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	ctr = ctr.WithExec([]string{"sh", "-c", driftBaselineScript})
+	if buf {
+		ctr = withBufPlugins(withBuf(ctr))
+	}
+	return ctr.
+		WithEnvVariable("DRIFT_BUF", fmt.Sprintf("%t", buf)).
+		WithExec([]string{"sh", "-c", driftScript}), nil
+}
+
+// withBuf installs the pinned version of buf.
+func withBuf(ctr *Container) *Container {
+	return ctr.WithExec([]string{
+		"go", "install",
+		fmt.Sprintf("github.com/bufbuild/buf/cmd/buf@%s", bufVersion),
+	})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	F "github.com/IBM/fp-go/function"
+)
+
+const (
+	flavourAlpine = "alpine"
+	flavourWolfi  = "wolfi"
+	flavourDebian = "debian"
+	// STATIC_BASE is the default production base, chainguard only publishes
+	// the latest tag publicly, pin a digest through ProductionImage for
+	// reproducible images
+	STATIC_BASE = "cgr.dev/chainguard/static:latest"
+	binaryPath  = "/out/app"
+	wolfiGoPath = "/root/go"
+)
+
+// WithBaseFlavour sets the base image of the test and build containers.
+// Wolfi packages Go per minor release, so it always provides the latest patch
+// release of the requested minor version. Sources declaring a newer patch
+// than the packaged one are rejected in auto mode.
+func (gom *Golang) WithBaseFlavour(
+	// One of alpine, wolfi or debian
+	// +optional
+	// +default="alpine"
+	flavour string,
+) (*Golang, error) {
+	switch flavour {
+	case flavourAlpine, flavourWolfi, flavourDebian:
+		gom.BaseFlavour = flavour
+		return gom, nil
+	default:
+		return gom, fmt.Errorf("unsupported base flavour %s", flavour)
+	}
+}
+
+// Build compiles a static binary of the given package.
+func (gom *Golang) Build(
+	ctx context.Context,
+	// The source directory to build, Required.
+	src *Directory,
+	// The package of the main function
+	// +optional
+	// +default="."
+	pkg string,
+	// An optional slice of strings representing additional arguments to the go build command
+	// +optional
+	args []string,
+) (*File, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return ctr.
+		WithEnvVariable("CGO_ENABLED", "0").
+		WithExec(append(
+			append([]string{
+				"go", "build", "-trimpath",
+				"-ldflags", "-s -w",
+				"-o", binaryPath,
+			}, args...),
+			pkg,
+		)).
+		File(binaryPath), nil
+}
+
+// ProductionImage builds the given package and copies the binary into a
+// minimal static image, no Dockerfile needed.
+func (gom *Golang) ProductionImage(
+	ctx context.Context,
+	// The source directory to build, Required.
+	src *Directory,
+	// The package of the main function
+	// +optional
+	// +default="."
+	pkg string,
+	// Name of the binary inside the image
+	// +optional
+	// +default="app"
+	name string,
+	// An optional slice of strings representing additional arguments to the go build command
+	// +optional
+	args []string,
+	// The base image, pin a digest such as
+	// cgr.dev/chainguard/static@sha256:<digest> for reproducible images
+	// +optional
+	// +default="cgr.dev/chainguard/static:latest"
+	baseImage string,
+) (*Container, error) {
+	bin, err := gom.Build(ctx, src, pkg, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(baseImage) == 0 {
+		baseImage = STATIC_BASE
+	}
+	binary := path.Join("/usr/bin", name)
+	return dag.Container().
+		From(baseImage).
+		WithFile(binary, bin).
+		WithEntrypoint([]string{binary}), nil
+}
+
+// goBase returns a container of the configured base flavour with the given
+// version of Go and git installed.
+func (gom *Golang) goBase(version string) *Container {
+	switch gom.baseFlavour() {
+	case flavourWolfi:
+		nums, _ := splitGoVersion(version)
+		return F.Pipe2(
+			dag.Container(),
+			base(WOLFI_BASE),
+			wolfiWithGoInstall(fmt.Sprintf("go-%d.%d", nums[0], nums[1])),
+		).
+			WithExec([]string{"apk", "add", "git"}).
+			WithEnvVariable("GOPATH", wolfiGoPath).
+			WithEnvVariable(
+				"PATH",
+				fmt.Sprintf("%s/bin:/usr/local/bin:/usr/bin:/bin", wolfiGoPath),
+			)
+	case flavourDebian:
+		return dag.Container().
+			From(fmt.Sprintf("golang:%s-bookworm", version))
+	default:
+		return dag.Container().
+			From(fmt.Sprintf("golang:%s-alpine", version)).
+			WithExec([]string{"apk", "add", "--no-cache", "git"})
+	}
+}
+
+// baseFlavour returns the configured base flavour, alpine when none is set.
+func (gom *Golang) baseFlavour() string {
+	if len(gom.BaseFlavour) == 0 {
+		return flavourAlpine
+	}
+	return gom.BaseFlavour
+}
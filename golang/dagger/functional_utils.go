@@ -1,15 +1,27 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
 	F "github.com/IBM/fp-go/function"
 )
 
+const (
+	execOutput = "/tmp/exec.out"
+	execCode   = "/tmp/exec.code"
+)
+
 var (
 	base               = F.Curry2(unCurriedBase)
 	wolfiWithGoInstall = F.Curry2(unCurriedwolfiWithGoInstall)
 	prepareWorkspace   = F.Bind12of3(uncurriedPrepareWorkspace)
 	goTestRunner       = F.Curry2(uncurriedGoTestRunner)
-	goLintRunner       = F.Curry2(uncurriedGoLintRunner)
+	goLintRunner       = F.Curry3(uncurriedGoLintRunner)
+	statusExec         = F.Curry2(uncurriedStatusExec)
+	withLintConfig     = F.Bind12of3(uncurriedWithLintConfig)
 	setupBuild         = F.Bind12of3(uncurriedSetupBuild)
 	dockerHubAuth      = F.Bind12of3(
 		F.Bind1of4(uncurriedRegistryAuth)("docker.io"),
@@ -30,12 +42,23 @@ func uncurriedGoTestRunner(cmd []string, ctr *Container) *Container {
 	return ctr.WithExec(append([]string{"go", "test", "./..."}, cmd...))
 }
 
-func uncurriedGoLintRunner(cmd []string, ctr *Container) *Container {
+func uncurriedGoLintRunner(config string, cmd []string, ctr *Container) *Container {
 	return ctr.WithExec(
-		append([]string{"golangci-lint", "run", "-c", ".golangci.yml"}, cmd...),
+		append([]string{"golangci-lint", "run", "-c", config}, cmd...),
 	)
 }
 
+func uncurriedWithLintConfig(
+	path string,
+	config *File,
+	ctr *Container,
+) *Container {
+	if config == nil {
+		return ctr
+	}
+	return ctr.WithMountedFile(path, config)
+}
+
 func modCache(ctr *Container) *Container {
 	return ctr.WithExec([]string{"go", "mod", "download"})
 
@@ -63,3 +86,29 @@ func uncurriedSetupBuild(src, dockerFile string, ctr *Container) *Container {
 		DockerBuild(DirectoryDockerBuildOpts{Dockerfile: dockerFile})
 }
 
+// uncurriedStatusExec runs the command through a shell that records its
+// combined output and exit code instead of failing the pipeline.
+func uncurriedStatusExec(cmd []string, ctr *Container) *Container {
+	return ctr.WithExec(append([]string{
+		"sh", "-c",
+		fmt.Sprintf(`"$@" > %s 2>&1; echo $? > %s`, execOutput, execCode),
+		"sh",
+	}, cmd...))
+}
+
+// execStatus returns the output and exit code recorded by statusExec.
+func execStatus(ctx context.Context, ctr *Container) (string, int, error) {
+	output, err := ctr.File(execOutput).Contents(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("error in reading command output %w", err)
+	}
+	code, err := ctr.File(execCode).Contents(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("error in reading command exit code %w", err)
+	}
+	exitCode, err := strconv.Atoi(strings.TrimSpace(code))
+	if err != nil {
+		return "", 0, fmt.Errorf("error in parsing exit code %q %w", code, err)
+	}
+	return output, exitCode, nil
+}
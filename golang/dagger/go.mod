@@ -5,11 +5,12 @@ go 1.21.7
 require (
 	github.com/IBM/fp-go v1.0.141
 	github.com/go-git/go-git/v5 v5.12.0
+	golang.org/x/mod v0.17.0
+	golang.org/x/sync v0.7.0
 )
 
 require (
 	github.com/rogpeppe/go-internal v1.12.0 // indirect
-	golang.org/x/sync v0.7.0 // indirect
 )
 
 require (
@@ -29,7 +30,6 @@ require (
 	github.com/skeema/knownhosts v1.2.2 // indirect
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
 	golang.org/x/crypto v0.24.0 // indirect
-	golang.org/x/mod v0.17.0 // indirect
 	golang.org/x/net v0.26.0 // indirect
 	golang.org/x/sys v0.21.0 // indirect
 	golang.org/x/tools v0.21.1-0.20240508182429-e35e4ccd0d2d // indirect
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const testJSONReport = "/tmp/gotestsum.json"
+
+// testEvent is a single event of the go test -json output.
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+}
+
+// WithRerunFails enables rerunning of failed tests through gotestsum.
+func (gom *Golang) WithRerunFails(
+	// Number of times a failed test is rerun
+	// +optional
+	// +default=2
+	attempts int,
+	// Skip the reruns when the first run has more failures than this
+	// +optional
+	// +default=10
+	maxFailures int,
+	// Fail when more tests than this only passed on a rerun, a negative
+	// value disables the check
+	// +optional
+	// +default=-1
+	maxFlaky int,
+) *Golang {
+	gom.RerunFails = attempts
+	gom.RerunMaxFailures = maxFailures
+	gom.MaxFlaky = maxFlaky
+	return gom
+}
+
+// testContainer prepares the test container with the source mounted as the
+// working directory and its module dependencies downloaded.
+func (gom *Golang) testContainer(
+	ctx context.Context,
+	src *Directory,
+) (*Container, error) {
+	version, err := gom.golangVersion(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return gom.versionedTestContainer(ctx, version, src)
+}
+
+// versionedTestContainer prepares the test container for the given version
+// of Go, regardless of the version declared by the source.
+func (gom *Golang) versionedTestContainer(
+	ctx context.Context,
+	version string,
+	src *Directory,
+) (*Container, error) {
+	ctr, err := gom.versionedSourceContainer(ctx, version, src)
+	if err != nil {
+		return nil, err
+	}
+	return ctr.WithExec([]string{"go", "mod", "download"}), nil
+}
+
+// sourceContainer prepares the test container with the source mounted as
+// the working directory.
+func (gom *Golang) sourceContainer(
+	ctx context.Context,
+	src *Directory,
+) (*Container, error) {
+	version, err := gom.golangVersion(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return gom.versionedSourceContainer(ctx, version, src)
+}
+
+// versionedSourceContainer prepares the source container for the given
+// version of Go.
+func (gom *Golang) versionedSourceContainer(
+	ctx context.Context,
+	version string,
+	src *Directory,
+) (*Container, error) {
+	ctr, err := gom.withPrivateModules(ctx, gom.prepareTestContainer(version))
+	if err != nil {
+		return nil, err
+	}
+	return ctr.
+		WithMountedDirectory(PROJ_MOUNT, src).
+		WithWorkdir(PROJ_MOUNT), nil
+}
+
+// testRun is the outcome of a gotestsum run after applying the rerun
+// policy.
+type testRun struct {
+	// Output of gotestsum with the flaky and failed tests appended
+	Output string
+	// Exit code, non zero when tests failed or too many were flaky
+	Code int
+	// Reason of the failure, empty when the run passed
+	Failure string
+	// go test json report of the run
+	Report string
+}
+
+// runTests runs gotestsum in the given container. With reruns enabled, the
+// tests that only passed on a rerun are reported as flaky separately from
+// the genuine failures.
+func (gom *Golang) runTests(
+	ctx context.Context,
+	ctr *Container,
+	args []string,
+) (string, error) {
+	run, err := gom.runTestSuite(ctx, ctr, args)
+	if err != nil {
+		return "", err
+	}
+	if run.Code != 0 {
+		return run.Output, fmt.Errorf("%s\n%s", run.Failure, run.Output)
+	}
+	return run.Output, nil
+}
+
+// runTestSuite runs gotestsum in the given container and applies the rerun
+// policy without failing on the outcome, for callers that aggregate several
+// runs.
+func (gom *Golang) runTestSuite(
+	ctx context.Context,
+	ctr *Container,
+	args []string,
+) (*testRun, error) {
+	ctr = statusExec(gom.gotestsumCmd(args))(ctr)
+	output, code, err := execStatus(ctx, ctr)
+	if err != nil {
+		return nil, err
+	}
+	report, err := ctr.File(testJSONReport).Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in reading gotestsum json report %w", err)
+	}
+	run := &testRun{Output: output, Code: code, Report: report}
+	var flaky, failed []string
+	if gom.RerunFails > 0 {
+		flaky, failed = rerunOutcomes(report)
+	}
+	if len(flaky) > 0 {
+		run.Output = fmt.Sprintf(
+			"%s\nFlaky tests (passed on rerun):\n  %s\n",
+			run.Output,
+			strings.Join(flaky, "\n  "),
+		)
+	}
+	switch {
+	case code != 0:
+		if len(failed) > 0 {
+			run.Output = fmt.Sprintf(
+				"%s\nFailed tests:\n  %s\n",
+				run.Output,
+				strings.Join(failed, "\n  "),
+			)
+		}
+		run.Failure = fmt.Sprintf("tests failed with exit code %d", code)
+	case gom.MaxFlaky >= 0 && len(flaky) > gom.MaxFlaky:
+		run.Code = 1
+		run.Failure = fmt.Sprintf(
+			"%d flaky tests exceed the allowed maximum of %d",
+			len(flaky), gom.MaxFlaky,
+		)
+	}
+	return run, nil
+}
+
+// gotestsumCmd builds the gotestsum command line for the given go test
+// arguments.
+func (gom *Golang) gotestsumCmd(args []string) []string {
+	cmd := []string{
+		"gotestsum",
+		"--format-hide-empty-pkg",
+		"--format", gom.GotestSumFormatter,
+		"--jsonfile", testJSONReport,
+	}
+	if gom.RerunFails <= 0 {
+		return append(append(cmd, "--"), args...)
+	}
+	// gotestsum needs the packages in its own flag to be able to rerun them
+	pkgs, flags := splitTestArgs(args)
+	cmd = append(cmd,
+		"--rerun-fails", strconv.Itoa(gom.RerunFails),
+		"--rerun-fails-max-failures", strconv.Itoa(gom.RerunMaxFailures),
+		"--packages", strings.Join(pkgs, " "),
+		"--",
+	)
+	return append(cmd, flags...)
+}
+
+// testValueFlags are the go test and build flags that take their value
+// from the next argument unless given as -flag=value.
+var testValueFlags = []string{
+	"asmflags", "bench", "benchtime", "blockprofile", "blockprofilerate",
+	"count", "covermode", "coverpkg", "coverprofile", "cpu", "cpuprofile",
+	"exec", "fuzz", "fuzzcachedir", "fuzzminimizetime", "fuzztime",
+	"gccgoflags", "gcflags", "installsuffix", "ldflags", "list", "memprofile",
+	"memprofilerate", "mod", "modfile", "mutexprofile", "mutexprofilefraction",
+	"o", "outputdir", "overlay", "p", "parallel", "pgo", "pkgdir", "run",
+	"shuffle", "skip", "tags", "timeout", "toolexec", "trace", "vet",
+}
+
+// splitTestArgs separates package patterns from the go test flags and
+// their values, defaulting to every package of the module. Everything after
+// -args is passed on to the test binary.
+func splitTestArgs(args []string) ([]string, []string) {
+	var pkgs, flags []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			pkgs = append(pkgs, arg)
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == "args" {
+			flags = append(flags, args[i:]...)
+			break
+		}
+		flags = append(flags, arg)
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if slices.Contains(testValueFlags, name) && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	if len(pkgs) == 0 {
+		pkgs = []string{"./..."}
+	}
+	return pkgs, flags
+}
+
+// rerunOutcomes inspects a go test json report containing reruns. It
+// returns the tests that failed at first but passed on a later run, and the
+// tests whose last run failed.
+func rerunOutcomes(report string) ([]string, []string) {
+	outcomes := make(map[string][]string)
+	scanner := bufio.NewScanner(strings.NewReader(report))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var evt testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &evt); err != nil {
+			continue
+		}
+		if len(evt.Test) == 0 || (evt.Action != "pass" && evt.Action != "fail") {
+			continue
+		}
+		name := fmt.Sprintf("%s.%s", evt.Package, evt.Test)
+		outcomes[name] = append(outcomes[name], evt.Action)
+	}
+	var flaky, failed []string
+	for name, results := range outcomes {
+		switch last := results[len(results)-1]; {
+		case last == "fail":
+			failed = append(failed, name)
+		case slices.Contains(results[:len(results)-1], "fail"):
+			flaky = append(flaky, name)
+		}
+	}
+	sort.Strings(flaky)
+	sort.Strings(failed)
+	return flaky, failed
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRerunOutcomes(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		flaky  []string
+		failed []string
+	}{
+		{
+			name: "repeated passes are not flaky",
+			events: []string{
+				`{"Action":"pass","Package":"p","Test":"TestA"}`,
+				`{"Action":"pass","Package":"p","Test":"TestA"}`,
+			},
+		},
+		{
+			name: "pass after a failure is flaky",
+			events: []string{
+				`{"Action":"fail","Package":"p","Test":"TestA"}`,
+				`{"Action":"pass","Package":"p","Test":"TestA"}`,
+			},
+			flaky: []string{"p.TestA"},
+		},
+		{
+			name: "failure on the last run is failed",
+			events: []string{
+				`{"Action":"pass","Package":"p","Test":"TestA"}`,
+				`{"Action":"fail","Package":"p","Test":"TestA"}`,
+			},
+			failed: []string{"p.TestA"},
+		},
+		{
+			name: "package events are ignored",
+			events: []string{
+				`{"Action":"fail","Package":"p"}`,
+				`{"Action":"output","Package":"p","Test":"TestA"}`,
+				`{"Action":"pass","Package":"p","Test":"TestA"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flaky, failed := rerunOutcomes(strings.Join(tt.events, "\n"))
+			if !slices.Equal(flaky, tt.flaky) {
+				t.Errorf("flaky = %v, want %v", flaky, tt.flaky)
+			}
+			if !slices.Equal(failed, tt.failed) {
+				t.Errorf("failed = %v, want %v", failed, tt.failed)
+			}
+		})
+	}
+}
+
+func TestSplitTestArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		pkgs  []string
+		flags []string
+	}{
+		{
+			name: "no arguments tests every package",
+			pkgs: []string{"./..."},
+		},
+		{
+			name:  "flags alone test every package",
+			args:  []string{"-race", "-run", "TestA"},
+			pkgs:  []string{"./..."},
+			flags: []string{"-race", "-run", "TestA"},
+		},
+		{
+			name:  "flag values are not packages",
+			args:  []string{"-coverprofile", "./cover.out", "./pkg/..."},
+			pkgs:  []string{"./pkg/..."},
+			flags: []string{"-coverprofile", "./cover.out"},
+		},
+		{
+			name:  "import paths are packages",
+			args:  []string{"-count=1", "github.com/x/y/pkg"},
+			pkgs:  []string{"github.com/x/y/pkg"},
+			flags: []string{"-count=1"},
+		},
+		{
+			name:  "arguments after -args belong to the test binary",
+			args:  []string{"./...", "-args", "-update", "golden"},
+			pkgs:  []string{"./..."},
+			flags: []string{"-args", "-update", "golden"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs, flags := splitTestArgs(tt.args)
+			if !slices.Equal(pkgs, tt.pkgs) {
+				t.Errorf("pkgs = %v, want %v", pkgs, tt.pkgs)
+			}
+			if !slices.Equal(flags, tt.flags) {
+				t.Errorf("flags = %v, want %v", flags, tt.flags)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	k3sImage      = "rancher/k3s"
+	k3sAlias      = "k3s"
+	k3sPort       = 6443
+	kubeConfig    = "/root/.kube/config"
+	manifestMount = "/manifests"
+	k3sTokenFile  = "/etc/rancher/k3s-tokens.csv"
+	// k3sReadyScript writes a kubeconfig authenticating with the static
+	// admin token against the service alias and waits until the API and the
+	// nodes are ready. Manifests, when mounted, are applied afterwards.
+	k3sReadyScript = `export KUBECONFIG=/tmp/kubeconfig
+k3s kubectl config set-cluster k3s --server="https://$K3S_HOST:6443" --insecure-skip-tls-verify=true > /dev/null
+k3s kubectl config set-credentials admin --token="$K3S_ADMIN_TOKEN" > /dev/null
+k3s kubectl config set-context k3s --cluster=k3s --user=admin > /dev/null
+k3s kubectl config use-context k3s > /dev/null
+for i in $(seq 1 90); do
+  k3s kubectl get --raw /readyz > /dev/null 2>&1 && break
+  sleep 2
+done
+k3s kubectl get --raw /readyz || exit 1
+k3s kubectl wait --for=condition=Ready node --all --timeout=120s || exit 1
+if [ -d /manifests ]; then
+  k3s kubectl apply --recursive -f /manifests || exit 1
+fi`
+)
+
+// WithK3sVersion sets the version of k3s to use for Kubernetes services.
+func (gom *Golang) WithK3sVersion(
+	// The version of k3s to use
+	// +optional
+	// +default="v1.30.4-k3s1"
+	version string,
+) *Golang {
+	gom.K3sVersion = version
+	return gom
+}
+
+// TestsWithKubernetes runs Go tests in a container with an ephemeral k3s
+// cluster. The kubeconfig of the cluster is exported through KUBECONFIG.
+func (gom *Golang) TestsWithKubernetes(
+	ctx context.Context,
+	// The source directory to test, Required.
+	src *Directory,
+	// An optional directory of manifests applied before the tests start
+	// +optional
+	manifests *Directory,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	ctr, err = gom.withKubernetes(ctx, "", manifests, ctr)
+	if err != nil {
+		return "", err
+	}
+	return gom.runTests(ctx, ctr, args)
+}
+
+// TestsWithKubernetesFromGithub fetches a GitHub repository and runs Go
+// tests with an ephemeral k3s cluster.
+func (gom *Golang) TestsWithKubernetesFromGithub(
+	ctx context.Context,
+	// The GitHub repository name (e.g., "username/repo")
+	repository string,
+	// The git reference (branch, tag, or commit) to clone and test
+	gitRef string,
+	// An optional directory of manifests applied before the tests start
+	// +optional
+	manifests *Directory,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	return gom.TestsWithKubernetes(
+		ctx,
+		githubCheckout(repository, gitRef),
+		manifests,
+		args,
+	)
+}
+
+// withKubernetesCluster binds a k3s cluster without any manifests applied.
+func (gom *Golang) withKubernetesCluster(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	return gom.withKubernetes(ctx, instance, nil, ctr)
+}
+
+// withKubernetes starts a k3s server, waits for its API, applies the
+// optional manifests and binds it to the container together with its
+// kubeconfig.
+func (gom *Golang) withKubernetes(
+	ctx context.Context,
+	instance string,
+	manifests *Directory,
+	ctr *Container,
+) (*Container, error) {
+	// the api server accepts a random admin token per cluster, the setup
+	// builds the kubeconfig from it instead of sharing files with the server
+	token, err := k3sAdminToken()
+	if err != nil {
+		return nil, err
+	}
+	tokenSecret := dag.SetSecret("k3s-admin-token-"+token[:8], token)
+	tokenFile := dag.SetSecret(
+		"k3s-token-file-"+token[:8],
+		fmt.Sprintf("%s,admin,admin,system:masters\n", token),
+	)
+	k3s, err := serviceInstance(instance, dag.Container().
+		From(fmt.Sprintf("%s:%s", k3sImage, gom.K3sVersion)).
+		WithMountedSecret(k3sTokenFile, tokenFile).
+		WithMountedTemp("/var/lib/rancher/k3s").
+		WithExposedPort(k3sPort),
+	).
+		WithExec(
+			[]string{
+				"k3s", "server",
+				"--disable=traefik",
+				"--disable=metrics-server",
+				fmt.Sprintf("--tls-san=%s", k3sAlias),
+				fmt.Sprintf("--kube-apiserver-arg=token-auth-file=%s", k3sTokenFile),
+			},
+			ContainerWithExecOpts{
+				SkipEntrypoint:           true,
+				InsecureRootCapabilities: true,
+			},
+		).
+		AsService().
+		Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in starting k3s service %w", err)
+	}
+	setup := uncached(dag.Container().
+		From(fmt.Sprintf("%s:%s", k3sImage, gom.K3sVersion))).
+		WithServiceBinding(k3sAlias, k3s).
+		WithEnvVariable("K3S_HOST", k3sAlias).
+		WithSecretVariable("K3S_ADMIN_TOKEN", tokenSecret)
+	if manifests != nil {
+		setup = setup.WithMountedDirectory(manifestMount, manifests)
+	}
+	setup, err = setup.WithExec(
+		[]string{"sh", "-c", k3sReadyScript},
+		ContainerWithExecOpts{SkipEntrypoint: true},
+	).Sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in waiting for k3s api %w", err)
+	}
+	return ctr.WithServiceBinding(k3sAlias, k3s).
+		WithFile(kubeConfig, setup.File("/tmp/kubeconfig")).
+		WithEnvVariable("KUBECONFIG", kubeConfig), nil
+}
+
+// k3sAdminToken generates a random static token for the k3s admin user.
+func k3sAdminToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error in generating k3s admin token %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	F "github.com/IBM/fp-go/function"
+)
+
+const (
+	repoLintConfig    = ".golangci.yml"
+	defaultLintConfig = "/etc/golangci-lint/.golangci.yml"
+	lintReportPath    = "/tmp/golangci-lint-report"
+	// sarifLintVersion is the first golangci-lint release with sarif output
+	sarifLintVersion = "1.59.0"
+)
+
+//go:embed golangci.yml
+var teamLintConfig string
+
+// lintReportFormats maps the supported golangci-lint output formats to the
+// extension of the generated report file.
+var lintReportFormats = map[string]string{
+	"checkstyle": "xml",
+	"sarif":      "sarif",
+	"json":       "json",
+}
+
+// LintReport runs golangci-lint and returns the issues as a machine-readable
+// report file instead of failing on them.
+func (gom *Golang) LintReport(
+	ctx context.Context,
+	// An optional string specifying the version of golangci-lint to use
+	// +optional
+	// +default="v1.55.2-alpine"
+	version string,
+	// The source directory to lint, Required.
+	src *Directory,
+	// Output format of the report, one of checkstyle, sarif or json. sarif
+	// requires golangci-lint v1.59.0 or later
+	// +optional
+	// +default="checkstyle"
+	format string,
+	// An optional golangci-lint configuration file, defaults to the
+	// .golangci.yml of the source or the built-in team configuration
+	// +optional
+	config *File,
+	// An optional git reference, only issues introduced after it are
+	// reported. The source must include its .git directory holding the
+	// reference
+	// +optional
+	baseRef string,
+	// An optional slice of strings representing additional arguments to the
+	// golangci-lint command
+	// +optional
+	args []string,
+) (*File, error) {
+	ext, ok := lintReportFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported lint report format %s", format)
+	}
+	if format == "sarif" && lintVersionBefore(version, sarifLintVersion) {
+		return nil, fmt.Errorf(
+			"sarif output requires golangci-lint v%s or later, got %s",
+			sarifLintVersion,
+			version,
+		)
+	}
+	ctr, cfgPath, err := gom.lintContainer(ctx, version, src, config)
+	if err != nil {
+		return nil, err
+	}
+	newIssues, err := newIssuesArgs(ctx, src, ctr, baseRef)
+	if err != nil {
+		return nil, err
+	}
+	report := fmt.Sprintf("%s.%s", lintReportPath, ext)
+	cmd := append(newIssues,
+		"--out-format", fmt.Sprintf("%s:%s", format, report),
+		"--issues-exit-code", "0",
+	)
+	return goLintRunner(cfgPath)(append(cmd, args...))(ctr).File(report), nil
+}
+
+// lintContainer prepares a golangci-lint container with the source and its
+// lint configuration. It returns the container and the path of the
+// configuration.
+func (gom *Golang) lintContainer(
+	ctx context.Context,
+	version string,
+	src *Directory,
+	config *File,
+) (*Container, string, error) {
+	cfgPath, cfg, err := lintConfig(ctx, src, config)
+	if err != nil {
+		return nil, "", err
+	}
+	ctr, err := gom.withPrivateModules(ctx, F.Pipe3(
+		dag.Container(),
+		base(fmt.Sprintf("%s:%s", LINT_BASE, version)),
+		prepareWorkspace(src, PROJ_MOUNT),
+		withLintConfig(cfgPath, cfg),
+	))
+	if err != nil {
+		return nil, "", err
+	}
+	return ctr, cfgPath, nil
+}
+
+// lintConfig resolves the golangci-lint configuration for the source. An
+// explicit config file wins, followed by the .golangci.yml of the source and
+// finally the built-in team configuration. It returns the path of the
+// configuration inside the lint container and the file that has to be
+// mounted there, which is nil when the source already provides it.
+func lintConfig(
+	ctx context.Context,
+	src *Directory,
+	config *File,
+) (string, *File, error) {
+	if config != nil {
+		return defaultLintConfig, config, nil
+	}
+	entries, err := src.Entries(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("error in listing source entries %w", err)
+	}
+	if slices.Contains(entries, repoLintConfig) {
+		return path.Join(PROJ_MOUNT, repoLintConfig), nil, nil
+	}
+	return defaultLintConfig,
+		dag.Directory().
+			WithNewFile("golangci.yml", teamLintConfig).
+			File("golangci.yml"),
+		nil
+}
+
+// newIssuesArgs returns the golangci-lint arguments that restrict the
+// reported issues to the ones introduced after the given git reference. It
+// fails when the source has no git history holding the reference, where
+// golangci-lint would otherwise silently report nothing.
+func newIssuesArgs(
+	ctx context.Context,
+	src *Directory,
+	ctr *Container,
+	baseRef string,
+) ([]string, error) {
+	if len(baseRef) == 0 {
+		return []string{}, nil
+	}
+	entries, err := src.Entries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in listing source entries %w", err)
+	}
+	if !slices.Contains(entries, ".git") {
+		return nil, fmt.Errorf(
+			"linting new issues from %s requires the .git directory in the source",
+			baseRef,
+		)
+	}
+	_, code, err := execStatus(ctx, statusExec([]string{
+		"git", "-c", "safe.directory=*",
+		"rev-parse", "--verify", "--quiet", baseRef + "^{commit}",
+	})(ctr))
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("git reference %s is not present in the source", baseRef)
+	}
+	return []string{"--new-from-rev", baseRef}, nil
+}
+
+// lintVersionBefore reports whether the golangci-lint image version, such as
+// v1.55.2-alpine, is older than the given release. Tags without a version,
+// such as latest, are never older.
+func lintVersionBefore(version, release string) bool {
+	version, _, _ = strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	if len(version) == 0 || version[0] < '0' || version[0] > '9' {
+		return false
+	}
+	return compareGoVersions(version, release) < 0
+}
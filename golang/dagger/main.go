@@ -5,8 +5,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-
-	F "github.com/IBM/fp-go/function"
 )
 
 const (
@@ -17,14 +15,35 @@ const (
 )
 
 type Golang struct {
-	ArangoPassword     string
-	ArangoVersion      string
-	ArangoPort         int
-	RedisPassword      string
-	RedisVersion       string
-	RedisPort          int
-	GolangVersion      string
-	GotestSumFormatter string
+	ArangoPassword        string
+	ArangoVersion         string
+	ArangoPort            int
+	ArangoDatabases       []string
+	ArangoUsers           []*ArangoUser
+	ArangoFixtures        *Directory
+	ArangoFixtureDatabase string
+	ArangoFixtureMethod   string
+	ArangoMode            string
+	ArangoDBServers       int
+	ArangoCoordinators    int
+	RedisPassword         *Secret
+	RedisTopology         string
+	RedisVersion          string
+	RedisPort             int
+	PostgresVersion       string
+	PostgresPassword      *Secret
+	K3sVersion            string
+	ObjectStoreBuckets    []string
+	NatsVersion           string
+	NatsStreams           []string
+	GolangVersion         string
+	BaseFlavour           string
+	PrivateModules        []string
+	PrivateModulesToken   *Secret
+	GotestSumFormatter    string
+	RerunFails            int
+	RerunMaxFailures      int
+	MaxFlaky              int
 }
 
 // Test runs Go tests
@@ -36,16 +55,11 @@ func (gom *Golang) Test(
 	// +optional
 	args []string,
 ) (string, error) {
-	return gom.PrepareTestContainer(ctx).
-		WithMountedDirectory(PROJ_MOUNT, src).
-		WithWorkdir(PROJ_MOUNT).
-		WithExec([]string{"go", "mod", "download"}).
-		WithExec(append([]string{
-			"gotestsum",
-			"--format-hide-empty-pkg",
-			"--format", gom.GotestSumFormatter, "--",
-		}, args...)).
-		Stdout(ctx)
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	return gom.runTests(ctx, ctr, args)
 }
 
 // Lint runs golangci-lint on the Go source code in a containerized environment.
@@ -62,13 +76,26 @@ func (gom *Golang) Lint(
 	// golangci-lint command
 	// +optional
 	args []string,
+	// An optional golangci-lint configuration file, defaults to the
+	// .golangci.yml of the source or the built-in team configuration
+	// +optional
+	config *File,
+	// An optional git reference, only issues introduced after it are
+	// reported. The source must include its .git directory holding the
+	// reference
+	// +optional
+	baseRef string,
 ) (string, error) {
-	return F.Pipe3(
-		dag.Container(),
-		base(LINT_BASE),
-		prepareWorkspace(src, PROJ_MOUNT),
-		goLintRunner(args),
-	).Stdout(ctx)
+	ctr, cfgPath, err := gom.lintContainer(ctx, version, src, config)
+	if err != nil {
+		return "", err
+	}
+	newIssues, err := newIssuesArgs(ctx, src, ctr, baseRef)
+	if err != nil {
+		return "", err
+	}
+	return goLintRunner(cfgPath)(append(newIssues, args...))(ctr).
+		Stdout(ctx)
 }
 
 func fetchAndValidateEnvVars(envVar string) (string, error) {
@@ -81,7 +108,8 @@ func fetchAndValidateEnvVars(envVar string) (string, error) {
 
 // WithGolangVersion sets the version of Golang to use.
 func (gom *Golang) WithGolangVersion(
-	// The version of Golang to use
+	// The version of Golang to use, auto picks the version declared by the
+	// go.mod or go.work of the source
 	// +optional
 	// +default="1.22.6"
 	version string,
@@ -104,10 +132,26 @@ func (gom *Golang) WithGotestSumFormatter(
 // PrepareTestContainer creates a container with Golang and installs gotestsum and gotestdox
 func (gom *Golang) PrepareTestContainer(
 	ctx context.Context,
-) *Container {
-	return dag.Container().
-		From(fmt.Sprintf("golang:%s-alpine", gom.GolangVersion)).
-		WithExec([]string{"apk", "add", "--no-cache", "git"}).
+	// The source directory whose go.mod or go.work picks the Go version in
+	// auto mode
+	// +optional
+	src *Directory,
+) (*Container, error) {
+	if gom.GolangVersion == autoGolangVersion && src == nil {
+		return nil, fmt.Errorf(
+			"the go version is set to %s, a source directory is required to resolve it",
+			autoGolangVersion,
+		)
+	}
+	version, err := gom.golangVersion(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return gom.withPrivateModules(ctx, gom.prepareTestContainer(version))
+}
+
+func (gom *Golang) prepareTestContainer(version string) *Container {
+	return gom.goBase(version).
 		WithExec([]string{"go", "install", "gotest.tools/gotestsum@latest"}).
 		WithExec([]string{"go", "install", "github.com/bitfield/gotestdox/cmd/gotestdox@latest"})
 }
@@ -129,3 +173,11 @@ func (gom *Golang) TestGitHub(
 		Checkout()
 	return gom.Test(ctx, source, args)
 }
+
+// githubCheckout clones a GitHub repository at the given git reference.
+func githubCheckout(repository, gitRef string) *Directory {
+	return dag.Gitter().
+		WithRef(gitRef).
+		WithRepository(fmt.Sprintf("%s/%s", githubURL, repository)).
+		Checkout()
+}
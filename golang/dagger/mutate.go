@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	gremlinsVersion = "v0.5.0"
+	gremlinsReport  = "/tmp/gremlins.json"
+	mutantKilled    = "KILLED"
+	mutantLived     = "LIVED"
+)
+
+// gremlinsResult is the json report of a gremlins run.
+type gremlinsResult struct {
+	Files []struct {
+		FileName  string `json:"file_name"`
+		Mutations []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+			Line   int    `json:"line"`
+			Column int    `json:"column"`
+		} `json:"mutations"`
+	} `json:"files"`
+}
+
+// mutationScore counts the outcome of the mutants of a package.
+type mutationScore struct {
+	Killed    int
+	Lived     int
+	Survivors []string
+}
+
+// Mutate runs mutation testing with gremlins and reports the mutation score
+// of every package, the share of mutants caught by the tests, together with
+// the surviving mutants.
+func (gom *Golang) Mutate(
+	ctx context.Context,
+	// The source directory to test, Required.
+	src *Directory,
+	// Packages to mutate relative to the module root, defaults to the whole
+	// module
+	// +optional
+	packages []string,
+	// Services bound to the test container, any of arangodb, redis, postgres,
+	// kubernetes, minio, gcs and nats
+	// +optional
+	services []string,
+) (string, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	ctr, err = gom.bindServices(ctx, "", services, ctr)
+	if err != nil {
+		return "", err
+	}
+	ctr = ctr.WithExec([]string{
+		"go", "install",
+		fmt.Sprintf("github.com/go-gremlins/gremlins/cmd/gremlins@%s", gremlinsVersion),
+	})
+	if len(packages) == 0 {
+		packages = []string{"."}
+	}
+	reports := make([]string, len(packages))
+	grp, gctx := errgroup.WithContext(ctx)
+	for idx, pkg := range packages {
+		idx, pkg := idx, pkg
+		grp.Go(func() error {
+			run := statusExec([]string{
+				"gremlins", "unleash", "--output", gremlinsReport, pkg,
+			})(ctr)
+			output, code, err := execStatus(gctx, run)
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				return fmt.Errorf("error in mutating %s\n%s", pkg, output)
+			}
+			report, err := run.File(gremlinsReport).Contents(gctx)
+			if err != nil {
+				return fmt.Errorf("error in reading gremlins report of %s %w", pkg, err)
+			}
+			reports[idx] = report
+			return nil
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return "", err
+	}
+	scores, err := mutationScores(reports)
+	if err != nil {
+		return "", err
+	}
+	return mutationReport(scores), nil
+}
+
+// mutationScores groups the mutants of the gremlins reports by package
+// directory.
+func mutationScores(reports []string) (map[string]*mutationScore, error) {
+	scores := make(map[string]*mutationScore)
+	for _, report := range reports {
+		var result gremlinsResult
+		if err := json.Unmarshal([]byte(report), &result); err != nil {
+			return nil, fmt.Errorf("error in decoding gremlins report %w", err)
+		}
+		for _, file := range result.Files {
+			dir := path.Dir(file.FileName)
+			score, ok := scores[dir]
+			if !ok {
+				score = &mutationScore{}
+				scores[dir] = score
+			}
+			for _, mutation := range file.Mutations {
+				switch mutation.Status {
+				case mutantKilled:
+					score.Killed++
+				case mutantLived:
+					score.Lived++
+					score.Survivors = append(score.Survivors, fmt.Sprintf(
+						"%s:%d:%d %s",
+						file.FileName, mutation.Line, mutation.Column, mutation.Type,
+					))
+				}
+			}
+		}
+	}
+	return scores, nil
+}
+
+// mutationReport renders the scores as a Markdown table followed by the
+// surviving mutants of every package.
+func mutationReport(scores map[string]*mutationScore) string {
+	dirs := make([]string, 0, len(scores))
+	for dir := range scores {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	var report strings.Builder
+	var survivors strings.Builder
+	report.WriteString("| package | killed | lived | score |\n")
+	report.WriteString("| --- | --- | --- | --- |\n")
+	for _, dir := range dirs {
+		score := scores[dir]
+		tested := score.Killed + score.Lived
+		if tested == 0 {
+			fmt.Fprintf(&report, "| %s | 0 | 0 | n/a |\n", dir)
+			continue
+		}
+		fmt.Fprintf(
+			&report,
+			"| %s | %d | %d | %.2f%% |\n",
+			dir, score.Killed, score.Lived,
+			float64(score.Killed)/float64(tested)*100,
+		)
+		if len(score.Survivors) > 0 {
+			sort.Strings(score.Survivors)
+			fmt.Fprintf(
+				&survivors,
+				"\n### %s\n\n- %s\n",
+				dir, strings.Join(score.Survivors, "\n- "),
+			)
+		}
+	}
+	if survivors.Len() > 0 {
+		report.WriteString("\n## Surviving mutants\n")
+		report.WriteString(survivors.String())
+	}
+	return report.String()
+}
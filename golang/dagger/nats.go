@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	natsBoxImage = "natsio/nats-box:0.14.5"
+	natsPort     = 4222
+)
+
+// WithNatsVersion sets the version of NATS to use.
+func (gom *Golang) WithNatsVersion(
+	// The version of NATS to use
+	// +optional
+	// +default="2.10"
+	version string,
+) *Golang {
+	gom.NatsVersion = version
+	return gom
+}
+
+// WithNatsStreams sets the JetStream streams created before the tests run.
+func (gom *Golang) WithNatsStreams(
+	// Streams in the form name:subject1,subject2, for example
+	// ORDERS:orders.>
+	streams []string,
+) (*Golang, error) {
+	for _, stream := range streams {
+		if _, _, err := parseNatsStream(stream); err != nil {
+			return gom, err
+		}
+	}
+	gom.NatsStreams = streams
+	return gom, nil
+}
+
+// TestsWithNats runs Go tests in a container with a JetStream enabled NATS
+// server.
+func (gom *Golang) TestsWithNats(
+	ctx context.Context,
+	// The source directory to test, Required.
+	src *Directory,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	ctr, err = gom.withNats(ctx, "", ctr)
+	if err != nil {
+		return "", err
+	}
+	return gom.runTests(ctx, ctr, args)
+}
+
+// TestsWithNatsFromGithub fetches a GitHub repository and runs Go tests with
+// NATS.
+func (gom *Golang) TestsWithNatsFromGithub(
+	ctx context.Context,
+	// The GitHub repository name (e.g., "username/repo")
+	repository string,
+	// The git reference (branch, tag, or commit) to clone and test
+	gitRef string,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	return gom.TestsWithNats(ctx, githubCheckout(repository, gitRef), args)
+}
+
+// withNats starts a NATS server with JetStream, creates the streams and
+// exports NATS_URL.
+func (gom *Golang) withNats(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	nats, err := serviceInstance(instance, dag.Container().
+		From(fmt.Sprintf("nats:%s-alpine", gom.NatsVersion)).
+		WithExposedPort(natsPort),
+	).
+		WithExec([]string{
+			"nats-server",
+			"--jetstream",
+			"--store_dir", "/data",
+			"--port", fmt.Sprintf("%d", natsPort),
+		}, ContainerWithExecOpts{SkipEntrypoint: true}).
+		AsService().
+		Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in starting nats service %w", err)
+	}
+	host, err := nats.Hostname(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in retrieving nats host %w", err)
+	}
+	url := fmt.Sprintf("nats://%s:%d", host, natsPort)
+	if len(gom.NatsStreams) > 0 {
+		setup := uncached(dag.Container().From(natsBoxImage)).
+			WithServiceBinding("nats", nats)
+		for _, stream := range gom.NatsStreams {
+			name, subjects, err := parseNatsStream(stream)
+			if err != nil {
+				return nil, err
+			}
+			setup = setup.WithExec([]string{
+				"nats", "--server", url,
+				"stream", "add", name,
+				"--subjects", subjects,
+				"--defaults",
+			}, ContainerWithExecOpts{SkipEntrypoint: true})
+		}
+		if _, err := setup.Sync(ctx); err != nil {
+			return nil, fmt.Errorf("error in creating nats streams %w", err)
+		}
+	}
+	return ctr.WithServiceBinding("nats", nats).
+		WithEnvVariable("NATS_URL", url), nil
+}
+
+// parseNatsStream splits a stream definition into its name and subjects.
+func parseNatsStream(stream string) (string, string, error) {
+	name, subjects, ok := strings.Cut(stream, ":")
+	if !ok || len(name) == 0 || len(subjects) == 0 {
+		return "", "", fmt.Errorf(
+			"invalid stream %q, expected name:subject1,subject2",
+			stream,
+		)
+	}
+	return name, subjects, nil
+}
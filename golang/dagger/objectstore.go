@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	objectStoreMinio = "minio"
+	objectStoreGCS   = "gcs"
+	minioImage       = "minio/minio:RELEASE.2024-08-17T01-24-54Z"
+	minioClientImage = "minio/mc:RELEASE.2024-08-17T11-33-50Z"
+	minioPort        = 9000
+	minioUser        = "minioadmin"
+	minioPassword    = "minioadmin"
+	gcsImage         = "fsouza/fake-gcs-server:1.49.3"
+	gcsAlias         = "gcs"
+	gcsPort          = 4443
+	gcsProject       = "test"
+)
+
+// WithObjectStoreBuckets sets the buckets created in the object store
+// emulators before the tests run.
+func (gom *Golang) WithObjectStoreBuckets(
+	// Names of the buckets to create
+	buckets []string,
+) *Golang {
+	gom.ObjectStoreBuckets = buckets
+	return gom
+}
+
+// TestsWithObjectStore runs Go tests in a container with an S3 or GCS
+// emulator. The standard endpoint and credential environment variables
+// point the client libraries at the emulator.
+func (gom *Golang) TestsWithObjectStore(
+	ctx context.Context,
+	// The source directory to test, Required.
+	src *Directory,
+	// The kind of object store, either minio for S3 or gcs
+	// +optional
+	// +default="minio"
+	kind string,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	ctr, err = gom.withObjectStore(ctx, "", kind, ctr)
+	if err != nil {
+		return "", err
+	}
+	return gom.runTests(ctx, ctr, args)
+}
+
+// TestsWithObjectStoreFromGithub fetches a GitHub repository and runs Go
+// tests with an S3 or GCS emulator.
+func (gom *Golang) TestsWithObjectStoreFromGithub(
+	ctx context.Context,
+	// The GitHub repository name (e.g., "username/repo")
+	repository string,
+	// The git reference (branch, tag, or commit) to clone and test
+	gitRef string,
+	// The kind of object store, either minio for S3 or gcs
+	// +optional
+	// +default="minio"
+	kind string,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	return gom.TestsWithObjectStore(
+		ctx,
+		githubCheckout(repository, gitRef),
+		kind,
+		args,
+	)
+}
+
+// withObjectStore binds the object store emulator of the given kind.
+func (gom *Golang) withObjectStore(
+	ctx context.Context,
+	instance string,
+	kind string,
+	ctr *Container,
+) (*Container, error) {
+	switch kind {
+	case objectStoreMinio:
+		return gom.withMinio(ctx, instance, ctr)
+	case objectStoreGCS:
+		return gom.withGCS(ctx, instance, ctr)
+	default:
+		return nil, fmt.Errorf("unsupported object store %s", kind)
+	}
+}
+
+// withMinio starts MinIO, creates the buckets and exports the S3 endpoint
+// and credentials.
+func (gom *Golang) withMinio(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	minio, err := serviceInstance(instance, dag.Container().From(minioImage)).
+		WithEnvVariable("MINIO_ROOT_USER", minioUser).
+		WithEnvVariable("MINIO_ROOT_PASSWORD", minioPassword).
+		WithExposedPort(minioPort).
+		WithExec(
+			[]string{"minio", "server", "/data"},
+			ContainerWithExecOpts{SkipEntrypoint: true},
+		).
+		AsService().
+		Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in starting minio service %w", err)
+	}
+	host, err := minio.Hostname(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in retrieving minio host %w", err)
+	}
+	endpoint := fmt.Sprintf("http://%s:%d", host, minioPort)
+	setup := uncached(dag.Container().From(minioClientImage)).
+		WithServiceBinding("minio", minio).
+		WithExec([]string{
+			"mc", "alias", "set", "local", endpoint, minioUser, minioPassword,
+		}, ContainerWithExecOpts{SkipEntrypoint: true}).
+		WithExec(
+			[]string{"mc", "ready", "local"},
+			ContainerWithExecOpts{SkipEntrypoint: true},
+		)
+	for _, bucket := range gom.ObjectStoreBuckets {
+		setup = setup.WithExec(
+			[]string{"mc", "mb", "--ignore-existing", fmt.Sprintf("local/%s", bucket)},
+			ContainerWithExecOpts{SkipEntrypoint: true},
+		)
+	}
+	if _, err := setup.Sync(ctx); err != nil {
+		return nil, fmt.Errorf("error in creating minio buckets %w", err)
+	}
+	return ctr.WithServiceBinding("minio", minio).
+		WithEnvVariable("AWS_ENDPOINT_URL", endpoint).
+		WithEnvVariable("AWS_ENDPOINT_URL_S3", endpoint).
+		WithEnvVariable("AWS_ACCESS_KEY_ID", minioUser).
+		WithEnvVariable("AWS_SECRET_ACCESS_KEY", minioPassword).
+		WithEnvVariable("AWS_REGION", "us-east-1"), nil
+}
+
+// withGCS starts fake-gcs-server with the buckets and exports the emulator
+// host.
+func (gom *Golang) withGCS(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	// fake-gcs-server creates a bucket for every directory of its data dir
+	data := dag.Directory()
+	for _, bucket := range gom.ObjectStoreBuckets {
+		data = data.WithNewDirectory(bucket)
+	}
+	gcs := serviceInstance(instance, dag.Container().From(gcsImage)).
+		WithDirectory("/data", data).
+		WithExposedPort(gcsPort).
+		WithExec([]string{
+			"/bin/fake-gcs-server",
+			"-scheme", "http",
+			"-port", fmt.Sprintf("%d", gcsPort),
+			"-public-host", fmt.Sprintf("%s:%d", gcsAlias, gcsPort),
+			"-backend", "memory",
+			"-data", "/data",
+		}, ContainerWithExecOpts{SkipEntrypoint: true}).
+		AsService()
+	return ctr.WithServiceBinding(gcsAlias, gcs).
+		WithEnvVariable(
+			"STORAGE_EMULATOR_HOST",
+			fmt.Sprintf("http://%s:%d", gcsAlias, gcsPort),
+		).
+		WithEnvVariable("GOOGLE_CLOUD_PROJECT", gcsProject), nil
+}
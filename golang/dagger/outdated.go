@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/sync/errgroup"
+)
+
+// OutdatedModule is a dependency with a newer version available.
+type OutdatedModule struct {
+	// Module path
+	Path string
+	// Version required by the go.mod
+	Current string
+	// Latest version within the same major version
+	Latest string
+	// Module path and version of the latest major version, empty when there
+	// is no newer major version
+	LatestMajor string
+	// Whether the module is required directly by the go.mod
+	Direct bool
+}
+
+// OutdatedReport lists the outdated dependencies of a Go module.
+type OutdatedReport struct {
+	Modules []*OutdatedModule
+}
+
+// listedModule is a module as reported by go list -m -json.
+type listedModule struct {
+	Path    string
+	Version string
+	Main    bool
+	Update  *listedModule
+	Error   *struct{ Err string }
+}
+
+// Markdown renders the report as a Markdown table.
+func (rep *OutdatedReport) Markdown() string {
+	var table strings.Builder
+	table.WriteString("| module | current | latest | major update | direct |\n")
+	table.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, mod := range rep.Modules {
+		fmt.Fprintf(
+			&table,
+			"| %s | %s | %s | %s | %t |\n",
+			mod.Path, mod.Current, mod.Latest, mod.LatestMajor, mod.Direct,
+		)
+	}
+	return table.String()
+}
+
+// Outdated reports the dependencies of the source that have newer versions
+// available, including newer major versions of the direct dependencies.
+func (gom *Golang) Outdated(
+	ctx context.Context,
+	// The source directory to check, Required.
+	src *Directory,
+	// Whether to include the dependencies not required directly by the
+	// go.mod
+	// +optional
+	// +default=true
+	indirect bool,
+) (*OutdatedReport, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	out, err := ctr.
+		WithExec([]string{"go", "list", "-m", "-u", "-json", "all"}).
+		Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in listing modules %w", err)
+	}
+	mods, err := parseModuleList(out)
+	if err != nil {
+		return nil, err
+	}
+	direct, err := directRequires(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	report := &OutdatedReport{}
+	grp, gctx := errgroup.WithContext(ctx)
+	for _, mod := range mods {
+		if mod.Main || (!direct[mod.Path] && !indirect) {
+			continue
+		}
+		outdated := &OutdatedModule{
+			Path:    mod.Path,
+			Current: mod.Version,
+			Latest:  mod.Version,
+			Direct:  direct[mod.Path],
+		}
+		if mod.Update != nil {
+			outdated.Latest = mod.Update.Version
+		}
+		if outdated.Direct {
+			grp.Go(func() error {
+				latest, err := latestMajor(gctx, ctr, outdated.Path)
+				if err != nil {
+					return err
+				}
+				outdated.LatestMajor = latest
+				return nil
+			})
+		}
+		report.Modules = append(report.Modules, outdated)
+	}
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+	var modules []*OutdatedModule
+	for _, mod := range report.Modules {
+		if mod.Latest != mod.Current || len(mod.LatestMajor) > 0 {
+			modules = append(modules, mod)
+		}
+	}
+	report.Modules = modules
+	return report, nil
+}
+
+// OutdatedUpdate upgrades the outdated direct dependencies of the source
+// within their major version, runs the tests against them and returns the
+// updated go.mod and go.sum. They are returned unchanged when every direct
+// dependency is up to date.
+func (gom *Golang) OutdatedUpdate(
+	ctx context.Context,
+	// The source directory to update, Required.
+	src *Directory,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (*Directory, error) {
+	report, err := gom.Outdated(ctx, src, false)
+	if err != nil {
+		return nil, err
+	}
+	cmd := []string{"go", "get"}
+	for _, mod := range report.Modules {
+		if mod.Latest != mod.Current {
+			cmd = append(cmd, fmt.Sprintf("%s@%s", mod.Path, mod.Latest))
+		}
+	}
+	if len(cmd) == 2 {
+		return dag.Directory().
+			WithFile("go.mod", src.File("go.mod")).
+			WithFile("go.sum", src.File("go.sum")), nil
+	}
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	ctr = ctr.
+		WithExec(cmd).
+		WithExec([]string{"go", "mod", "tidy"})
+	if _, err := gom.runTests(ctx, ctr, args); err != nil {
+		return nil, fmt.Errorf("error in testing updated dependencies %w", err)
+	}
+	return dag.Directory().
+		WithFile("go.mod", ctr.File(path.Join(PROJ_MOUNT, "go.mod"))).
+		WithFile("go.sum", ctr.File(path.Join(PROJ_MOUNT, "go.sum"))), nil
+}
+
+// directRequires returns the modules required by the go.mod of the source
+// without an indirect comment. go list only marks the indirect requirements
+// of go.mod, modules that are only part of the module graph are not marked.
+func directRequires(ctx context.Context, src *Directory) (map[string]bool, error) {
+	content, err := src.File("go.mod").Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in reading go.mod %w", err)
+	}
+	mod, err := modfile.ParseLax("go.mod", []byte(content), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error in parsing go.mod %w", err)
+	}
+	direct := make(map[string]bool, len(mod.Require))
+	for _, req := range mod.Require {
+		if !req.Indirect {
+			direct[req.Mod.Path] = true
+		}
+	}
+	return direct, nil
+}
+
+// parseModuleList decodes the stream of json objects printed by
+// go list -m -json.
+func parseModuleList(out string) ([]*listedModule, error) {
+	var mods []*listedModule
+	dec := json.NewDecoder(strings.NewReader(out))
+	for {
+		mod := &listedModule{}
+		err := dec.Decode(mod)
+		if errors.Is(err, io.EOF) {
+			return mods, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error in decoding module list %w", err)
+		}
+		mods = append(mods, mod)
+	}
+}
+
+// latestMajor probes the successive major versions of a module and returns
+// the path and version of the newest one, empty when there is none.
+func latestMajor(
+	ctx context.Context,
+	ctr *Container,
+	modPath string,
+) (string, error) {
+	var latest string
+	for next, ok := nextMajorPath(modPath); ok; next, ok = nextMajorPath(next) {
+		// with -e a missing major version is reported in the json output
+		// instead of failing the command
+		out, err := ctr.
+			WithExec([]string{"go", "list", "-m", "-e", "-json", next + "@latest"}).
+			Stdout(ctx)
+		if err != nil {
+			return "", fmt.Errorf("error in querying %s %w", next, err)
+		}
+		mods, err := parseModuleList(out)
+		if err != nil {
+			return "", err
+		}
+		if len(mods) == 0 || mods[0].Error != nil {
+			break
+		}
+		latest = fmt.Sprintf("%s@%s", next, mods[0].Version)
+	}
+	return latest, nil
+}
+
+// nextMajorPath returns the module path of the next major version, following
+// the /vN convention or the .vN convention of gopkg.in.
+func nextMajorPath(modPath string) (string, bool) {
+	prefix, pathMajor, ok := module.SplitPathVersion(modPath)
+	if !ok {
+		return "", false
+	}
+	major := 1
+	if len(pathMajor) > 0 {
+		num, err := strconv.Atoi(pathMajor[2:])
+		if err != nil {
+			return "", false
+		}
+		major = num
+	}
+	if strings.HasPrefix(modPath, "gopkg.in/") {
+		return fmt.Sprintf("%s.v%d", prefix, major+1), true
+	}
+	return fmt.Sprintf("%s/v%d", prefix, major+1), true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	postgresPort           = 5432
+	postgresUser           = "postgres"
+	defaultPostgresVersion = "16"
+)
+
+// WithPostgresVersion sets the version of PostgreSQL to use.
+func (gom *Golang) WithPostgresVersion(
+	// The version of PostgreSQL to use
+	// +optional
+	// +default="16"
+	version string,
+) *Golang {
+	gom.PostgresVersion = version
+	return gom
+}
+
+// WithPostgresPassword sets the password of the postgres superuser, without
+// it the server trusts every connection.
+func (gom *Golang) WithPostgresPassword(
+	// The password of the postgres superuser
+	password *Secret,
+) *Golang {
+	gom.PostgresPassword = password
+	return gom
+}
+
+// withPostgres starts a PostgreSQL server and exports its connection details
+// as the standard libpq environment variables.
+func (gom *Golang) withPostgres(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	version := gom.PostgresVersion
+	if len(version) == 0 {
+		version = defaultPostgresVersion
+	}
+	server := serviceInstance(instance, dag.Container().
+		From(fmt.Sprintf("postgres:%s-alpine", version)).
+		WithExposedPort(postgresPort),
+	)
+	if gom.PostgresPassword != nil {
+		server = server.WithSecretVariable("POSTGRES_PASSWORD", gom.PostgresPassword)
+	} else {
+		server = server.WithEnvVariable("POSTGRES_HOST_AUTH_METHOD", "trust")
+	}
+	postgres, err := server.AsService().Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in starting postgres service %w", err)
+	}
+	host, err := postgres.Hostname(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in retrieving postgres host %w", err)
+	}
+	ctr = ctr.WithServiceBinding("postgres", postgres).
+		WithEnvVariable("PGHOST", host).
+		WithEnvVariable("PGPORT", fmt.Sprintf("%d", postgresPort)).
+		WithEnvVariable("PGUSER", postgresUser).
+		WithEnvVariable("PGDATABASE", postgresUser)
+	if gom.PostgresPassword != nil {
+		ctr = ctr.WithSecretVariable("PGPASSWORD", gom.PostgresPassword)
+	}
+	return ctr, nil
+}
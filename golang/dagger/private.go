@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const netrcPath = "/root/.netrc"
+
+// WithPrivateModules sets the private Go modules and the GitHub token used to
+// fetch them.
+func (gom *Golang) WithPrivateModules(
+	// Module path patterns of the private modules, for example
+	// github.com/dictybase/*
+	patterns []string,
+	// GitHub token with read access to the private repositories
+	token *Secret,
+) *Golang {
+	gom.PrivateModules = patterns
+	gom.PrivateModulesToken = token
+	return gom
+}
+
+// withPrivateModules configures the go tool to fetch the private modules
+// directly from GitHub. The credentials are mounted as a secret, so they
+// never end up in an image layer.
+func (gom *Golang) withPrivateModules(
+	ctx context.Context,
+	ctr *Container,
+) (*Container, error) {
+	if len(gom.PrivateModules) == 0 {
+		return ctr, nil
+	}
+	patterns := strings.Join(gom.PrivateModules, ",")
+	ctr = ctr.WithEnvVariable("GOPRIVATE", patterns).
+		WithEnvVariable("GONOSUMDB", patterns)
+	if gom.PrivateModulesToken == nil {
+		return ctr, nil
+	}
+	token, err := gom.PrivateModulesToken.Plaintext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in reading private modules token %w", err)
+	}
+	netrc := dag.SetSecret(
+		"private-modules-netrc",
+		fmt.Sprintf("machine github.com\nlogin x-access-token\npassword %s\n", token),
+	)
+	return ctr.WithMountedSecret(netrcPath, netrc), nil
+}
@@ -27,6 +27,26 @@ func (gom *Golang) WithRedisPort(
 	return gom
 }
 
+// WithRedisPassword sets the password required by the Redis servers.
+func (gom *Golang) WithRedisPassword(
+	// The password of the Redis servers
+	password *Secret,
+) *Golang {
+	gom.RedisPassword = password
+	return gom
+}
+
+// WithRedisTopology sets how the Redis servers are deployed.
+func (gom *Golang) WithRedisTopology(
+	// One of standalone, sentinel or cluster
+	// +optional
+	// +default="standalone"
+	topology string,
+) *Golang {
+	gom.RedisTopology = topology
+	return gom
+}
+
 // TestsWithRedis runs Go tests in a container with Redis.
 func (gom *Golang) TestsWithRedis(
 	ctx context.Context,
@@ -36,30 +56,56 @@ func (gom *Golang) TestsWithRedis(
 	// +optional
 	args []string,
 ) (string, error) {
-	redisService := dag.Container().
-		From(fmt.Sprintf("redis:%s-alpine", gom.RedisVersion)).
-		WithExposedPort(gom.RedisPort).
-		AsService()
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	ctr, err = gom.withRedis(ctx, "", ctr)
+	if err != nil {
+		return "", err
+	}
+	return gom.runTests(ctx, ctr, args)
+}
+
+// withRedis binds the Redis services of the configured topology to the
+// container and exports their connection details.
+func (gom *Golang) withRedis(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	switch gom.RedisTopology {
+	case "", redisStandalone:
+		return gom.withRedisStandalone(ctx, instance, ctr)
+	case redisSentinel:
+		return gom.withRedisSentinel(ctx, instance, ctr)
+	case redisCluster:
+		return gom.withRedisCluster(ctx, instance, ctr)
+	default:
+		return nil, fmt.Errorf("unsupported redis topology %s", gom.RedisTopology)
+	}
+}
+
+// withRedisStandalone binds a single Redis server to the container.
+func (gom *Golang) withRedisStandalone(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	redisService := gom.redisServer(nil, gom.redisNode(instance, "")).AsService()
 
 	redisHost, err := redisService.Hostname(ctx)
 	if err != nil {
-		return redisHost, fmt.Errorf(
+		return nil, fmt.Errorf(
 			"error in retrieving redis host %w",
 			err,
 		)
 	}
 
-	return gom.PrepareTestContainer(ctx).
+	return gom.withRedisPassword(ctr).
 		WithServiceBinding("redis", redisService).
 		WithEnvVariable("REDIS_SERVICE_HOST", redisHost).
-		WithEnvVariable("REDIS_SERVICE_PORT", fmt.Sprintf("%d", gom.RedisPort)).
-		WithMountedDirectory(PROJ_MOUNT, src).
-		WithWorkdir(PROJ_MOUNT).
-		WithExec([]string{"go", "mod", "download"}).
-		WithExec(append([]string{
-			"gotestsum", "--format", gom.GotestSumFormatter, "--",
-		}, args...)).
-		Stdout(ctx)
+		WithEnvVariable("REDIS_SERVICE_PORT", fmt.Sprintf("%d", gom.RedisPort)), nil
 }
 
 // TestsWithRedisFromGithub fetches a GitHub repository and runs Go tests with
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	redisStandalone    = "standalone"
+	redisSentinel      = "sentinel"
+	redisCluster       = "cluster"
+	redisSentinelPort  = 26379
+	redisSentinelCount = 3
+	redisSentinelName  = "mymaster"
+	redisClusterNodes  = 6
+	// redisServerScript starts a server that requires the password when one
+	// is set.
+	redisServerScript = `if [ -n "$REDIS_PASSWORD" ]; then
+  set -- --requirepass "$REDIS_PASSWORD" --masterauth "$REDIS_PASSWORD" "$@"
+fi
+exec redis-server --port "$REDIS_PORT" "$@"`
+	// redisSentinelScript writes the sentinel configuration monitoring the
+	// master and starts the sentinel.
+	redisSentinelScript = `{
+  echo "port $REDIS_SENTINEL_PORT"
+  echo "sentinel resolve-hostnames yes"
+  echo "sentinel monitor $REDIS_SENTINEL_NAME $REDIS_MASTER_HOST $REDIS_PORT 2"
+  echo "sentinel down-after-milliseconds $REDIS_SENTINEL_NAME 5000"
+  echo "sentinel failover-timeout $REDIS_SENTINEL_NAME 10000"
+  if [ -n "$REDIS_PASSWORD" ]; then
+    echo "sentinel auth-pass $REDIS_SENTINEL_NAME $REDIS_PASSWORD"
+  fi
+} > /tmp/sentinel.conf
+exec redis-server /tmp/sentinel.conf --sentinel`
+	// redisClusterScript joins the given nodes into a cluster with one
+	// replica per master and waits until the cluster is usable.
+	redisClusterScript = `nodes=""
+for host in "$@"; do
+  nodes="$nodes $(getent hosts "$host" | awk '{print $1}'):$REDIS_PORT"
+done
+redis-cli --cluster create $nodes --cluster-replicas 1 --cluster-yes || exit 1
+for i in $(seq 1 30); do
+  redis-cli -h "$1" -p "$REDIS_PORT" cluster info | grep -q cluster_state:ok && exit 0
+  sleep 1
+done
+echo "redis cluster is not ready after 30 seconds" >&2
+exit 1`
+)
+
+// redisNode prepares a Redis server container, the node name keeps the
+// otherwise identical servers of a topology apart.
+func (gom *Golang) redisNode(instance, node string) *Container {
+	ctr := serviceInstance(instance, dag.Container().
+		From(fmt.Sprintf("redis:%s-alpine", gom.RedisVersion)).
+		WithEnvVariable("REDIS_PORT", fmt.Sprintf("%d", gom.RedisPort)),
+	)
+	if len(node) > 0 {
+		ctr = ctr.WithEnvVariable("REDIS_NODE", node)
+	}
+	if gom.RedisPassword != nil {
+		ctr = ctr.WithSecretVariable("REDIS_PASSWORD", gom.RedisPassword)
+	}
+	return ctr
+}
+
+// redisServer starts redis-server with the given additional arguments.
+func (gom *Golang) redisServer(args []string, ctr *Container) *Container {
+	return ctr.WithExposedPort(gom.RedisPort).
+		WithExec(
+			append([]string{"sh", "-c", redisServerScript, "sh"}, args...),
+			ContainerWithExecOpts{SkipEntrypoint: true},
+		)
+}
+
+// withRedisPassword exports the Redis password to the container, both for
+// the tests and for redis-cli.
+func (gom *Golang) withRedisPassword(ctr *Container) *Container {
+	if gom.RedisPassword == nil {
+		return ctr
+	}
+	return ctr.WithSecretVariable("REDIS_SERVICE_PASSWORD", gom.RedisPassword).
+		WithSecretVariable("REDISCLI_AUTH", gom.RedisPassword)
+}
+
+// withRedisSentinel binds a master, a replica and a quorum of sentinels
+// monitoring them to the container.
+func (gom *Golang) withRedisSentinel(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	port := fmt.Sprintf("%d", gom.RedisPort)
+	master := gom.redisServer(nil, gom.redisNode(instance, "master")).AsService()
+	masterHost, err := master.Hostname(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in retrieving redis master host %w", err)
+	}
+	replica := gom.redisServer(
+		[]string{"--replicaof", masterHost, port},
+		gom.redisNode(instance, "replica").
+			WithServiceBinding("redis-master", master),
+	).AsService()
+	ctr = gom.withRedisPassword(ctr).
+		WithServiceBinding("redis-master", master).
+		WithServiceBinding("redis-replica", replica)
+	addrs := make([]string, 0, redisSentinelCount)
+	for idx := 0; idx < redisSentinelCount; idx++ {
+		sentinel := gom.redisNode(instance, fmt.Sprintf("sentinel-%d", idx)).
+			WithServiceBinding("redis-master", master).
+			WithEnvVariable("REDIS_MASTER_HOST", masterHost).
+			WithEnvVariable("REDIS_SENTINEL_NAME", redisSentinelName).
+			WithEnvVariable("REDIS_SENTINEL_PORT", fmt.Sprintf("%d", redisSentinelPort)).
+			WithExposedPort(redisSentinelPort).
+			WithExec(
+				[]string{"sh", "-c", redisSentinelScript},
+				ContainerWithExecOpts{SkipEntrypoint: true},
+			).
+			AsService()
+		host, err := sentinel.Hostname(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error in retrieving redis sentinel host %w", err)
+		}
+		addrs = append(addrs, fmt.Sprintf("%s:%d", host, redisSentinelPort))
+		ctr = ctr.WithServiceBinding(fmt.Sprintf("redis-sentinel-%d", idx), sentinel)
+	}
+	return ctr.
+		WithEnvVariable("REDIS_SERVICE_HOST", masterHost).
+		WithEnvVariable("REDIS_SERVICE_PORT", port).
+		WithEnvVariable("REDIS_SENTINEL_MASTER", redisSentinelName).
+		WithEnvVariable("REDIS_SENTINEL_ADDRS", strings.Join(addrs, ",")), nil
+}
+
+// withRedisCluster starts the nodes of a Redis cluster, joins them and binds
+// them to the container.
+func (gom *Golang) withRedisCluster(
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error) {
+	setup := gom.withRedisPassword(uncached(gom.redisNode(instance, "")))
+	ctr = gom.withRedisPassword(ctr)
+	aliases := make([]string, 0, redisClusterNodes)
+	addrs := make([]string, 0, redisClusterNodes)
+	for idx := 0; idx < redisClusterNodes; idx++ {
+		alias := fmt.Sprintf("redis-node-%d", idx)
+		node := gom.redisServer(
+			[]string{
+				"--cluster-enabled", "yes",
+				"--cluster-config-file", "/tmp/nodes.conf",
+				"--cluster-node-timeout", "5000",
+			},
+			gom.redisNode(instance, alias),
+		).AsService()
+		host, err := node.Hostname(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error in retrieving redis node host %w", err)
+		}
+		// the cluster state lives in the running nodes, they have to keep
+		// running until the tests are done
+		node, err = node.Start(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error in starting redis node %w", err)
+		}
+		aliases = append(aliases, alias)
+		addrs = append(addrs, fmt.Sprintf("%s:%d", host, gom.RedisPort))
+		setup = setup.WithServiceBinding(alias, node)
+		ctr = ctr.WithServiceBinding(alias, node)
+	}
+	_, err := setup.WithExec(
+		append([]string{"sh", "-c", redisClusterScript, "sh"}, aliases...),
+		ContainerWithExecOpts{SkipEntrypoint: true},
+	).Sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating redis cluster %w", err)
+	}
+	return ctr.
+		WithEnvVariable("REDIS_SERVICE_HOST", strings.Split(addrs[0], ":")[0]).
+		WithEnvVariable("REDIS_SERVICE_PORT", fmt.Sprintf("%d", gom.RedisPort)).
+		WithEnvVariable("REDIS_CLUSTER_ADDRS", strings.Join(addrs, ",")), nil
+}
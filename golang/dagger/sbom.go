@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	syftImage         = "anchore/syft:v1.11.1"
+	goLicensesVersion = "v1.6.0"
+	sbomPath          = "/tmp/sbom.json"
+)
+
+// sbomFormats maps the supported SBOM formats to the syft output format.
+var sbomFormats = map[string]string{
+	"cyclonedx": "cyclonedx-json",
+	"spdx":      "spdx-json",
+}
+
+// moduleLicense is the detected license of a Go module.
+type moduleLicense struct {
+	Module  string
+	License string
+}
+
+// SBOM generates a software bill of materials of the Go modules the source
+// depends on.
+func (gom *Golang) SBOM(
+	ctx context.Context,
+	// The source directory to inventory, Required.
+	src *Directory,
+	// Format of the SBOM, either cyclonedx or spdx
+	// +optional
+	// +default="cyclonedx"
+	format string,
+) (*File, error) {
+	output, ok := sbomFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sbom format %s", format)
+	}
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return ctr.
+		WithFile("/usr/local/bin/syft", dag.Container().From(syftImage).File("/syft")).
+		// licenses are read from the downloaded modules
+		WithEnvVariable("SYFT_GOLANG_SEARCH_LOCAL_MOD_CACHE_LICENSES", "true").
+		WithExec([]string{
+			"syft", "scan", fmt.Sprintf("dir:%s", PROJ_MOUNT),
+			"--output", fmt.Sprintf("%s=%s", output, sbomPath),
+		}).
+		File(sbomPath), nil
+}
+
+// Licenses detects the licenses of the Go modules the source depends on and
+// fails when any of them is on the denylist.
+func (gom *Golang) Licenses(
+	ctx context.Context,
+	// The source directory to inspect, Required.
+	src *Directory,
+	// License identifiers that are not allowed, an entry also matches the
+	// versions of the license, for example AGPL matches AGPL-3.0
+	// +optional
+	// +default=["AGPL", "GPL-2.0", "GPL-3.0", "SSPL"]
+	denylist []string,
+) (string, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	out, err := ctr.
+		WithExec([]string{
+			"go", "install",
+			fmt.Sprintf("github.com/google/go-licenses@%s", goLicensesVersion),
+		}).
+		WithExec([]string{"go-licenses", "report", "./..."}).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in detecting licenses %w", err)
+	}
+	licenses, err := parseLicenseReport(out)
+	if err != nil {
+		return "", err
+	}
+	var report strings.Builder
+	var denied []string
+	for _, lic := range licenses {
+		fmt.Fprintf(&report, "%s\t%s\n", lic.Module, lic.License)
+		if isDeniedLicense(lic.License, denylist) {
+			denied = append(denied, fmt.Sprintf("%s (%s)", lic.Module, lic.License))
+		}
+	}
+	if len(denied) > 0 {
+		return report.String(), fmt.Errorf(
+			"modules with disallowed licenses:\n%s",
+			strings.Join(denied, "\n"),
+		)
+	}
+	return report.String(), nil
+}
+
+// parseLicenseReport parses the module,url,license csv of go-licenses.
+func parseLicenseReport(report string) ([]moduleLicense, error) {
+	records, err := csv.NewReader(strings.NewReader(report)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error in parsing license report %w", err)
+	}
+	licenses := make([]moduleLicense, 0, len(records))
+	for _, rec := range records {
+		if len(rec) < 3 {
+			continue
+		}
+		licenses = append(licenses, moduleLicense{Module: rec[0], License: rec[2]})
+	}
+	sort.Slice(licenses, func(i, j int) bool {
+		return licenses[i].Module < licenses[j].Module
+	})
+	return licenses, nil
+}
+
+func isDeniedLicense(license string, denylist []string) bool {
+	for _, denied := range denylist {
+		if strings.HasPrefix(strings.ToUpper(license), strings.ToUpper(denied)) {
+			return true
+		}
+	}
+	return false
+}
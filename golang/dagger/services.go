@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// serviceBinder attaches a backing service and its connection details to a
+// test container.
+type serviceBinder func(
+	gom *Golang,
+	ctx context.Context,
+	instance string,
+	ctr *Container,
+) (*Container, error)
+
+// serviceBinders lists the services that can be attached to test containers
+// by name.
+var serviceBinders = map[string]serviceBinder{
+	"arangodb":   (*Golang).withArangoDB,
+	"redis":      (*Golang).withRedis,
+	"postgres":   (*Golang).withPostgres,
+	"kubernetes": (*Golang).withKubernetesCluster,
+	"minio":      (*Golang).withMinio,
+	"gcs":        (*Golang).withGCS,
+	"nats":       (*Golang).withNats,
+}
+
+// bindServices attaches the named services to the container.
+func (gom *Golang) bindServices(
+	ctx context.Context,
+	instance string,
+	services []string,
+	ctr *Container,
+) (*Container, error) {
+	for _, name := range services {
+		binder, ok := serviceBinders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown service %s", name)
+		}
+		bound, err := binder(gom, ctx, instance, ctr)
+		if err != nil {
+			return nil, err
+		}
+		ctr = bound
+	}
+	return ctr, nil
+}
+
+// serviceInstance tags a service container with an instance name. Dagger
+// shares services with identical definitions, the tag keeps them apart.
+func serviceInstance(instance string, ctr *Container) *Container {
+	if len(instance) == 0 {
+		return ctr
+	}
+	return ctr.WithEnvVariable("DAGGER_SERVICE_INSTANCE", instance)
+}
+
+// uncached keeps a setup container from being served from the Dagger cache,
+// the services it prepares are started afresh for every run.
+func uncached(ctr *Container) *Container {
+	return ctr.WithEnvVariable("CACHE_BUSTER", time.Now().String())
+}
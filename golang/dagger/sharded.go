@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// testPackage is a Go package scheduled on a test shard.
+type testPackage struct {
+	ImportPath string
+	Dir        string
+	Weight     float64
+}
+
+const shardReport = "test-report.json"
+
+// shardResult holds the outcome of the tests of a single shard.
+type shardResult struct {
+	Packages []testPackage
+	Output   string
+	Code     int
+	Failure  string
+	Report   string
+}
+
+// ShardedRun is the outcome of the tests of every shard.
+type ShardedRun struct {
+	// Output of the tests of every shard
+	Output string
+	// Merged go test json report of every shard, it can be passed as the
+	// timings of the next run
+	Report *File
+}
+
+// TestSharded splits the packages of the source into balanced shards and
+// tests every shard concurrently in its own container. The rerun policy of
+// WithRerunFails applies to every shard.
+func (gom *Golang) TestSharded(
+	ctx context.Context,
+	// The source directory to test, Required.
+	src *Directory,
+	// Number of shards to run concurrently
+	// +optional
+	// +default=4
+	shards int,
+	// An optional go test json report of a previous run, used to balance the
+	// shards by package duration
+	// +optional
+	timings *File,
+	// Services bound to every shard, any of arangodb, redis, postgres,
+	// kubernetes, minio, gcs and nats
+	// +optional
+	services []string,
+	// An optional slice of strings representing additional go test flags
+	// +optional
+	args []string,
+) (*ShardedRun, error) {
+	if shards < 1 {
+		return nil, fmt.Errorf("number of shards must be positive, got %d", shards)
+	}
+	pkgs, err := gom.listPackages(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if timings != nil {
+		report, err := timings.Contents(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error in reading timings report %w", err)
+		}
+		weighPackages(pkgs, packageDurations(report))
+	}
+	planned := shardPackages(pkgs, shards)
+	results := make([]shardResult, len(planned))
+	grp, gctx := errgroup.WithContext(ctx)
+	for idx, shard := range planned {
+		idx, shard := idx, shard
+		grp.Go(func() error {
+			result, err := gom.runShard(gctx, src, idx, shard, services, args)
+			if err != nil {
+				return fmt.Errorf("error in running shard %d %w", idx, err)
+			}
+			results[idx] = result
+			return nil
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+	return mergeShardResults(results)
+}
+
+// runShard tests the packages of a single shard with its own services.
+func (gom *Golang) runShard(
+	ctx context.Context,
+	src *Directory,
+	idx int,
+	pkgs []testPackage,
+	services []string,
+	args []string,
+) (shardResult, error) {
+	result := shardResult{Packages: pkgs}
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return result, err
+	}
+	ctr, err = gom.bindServices(
+		ctx,
+		fmt.Sprintf("shard-%d", idx),
+		services,
+		ctr,
+	)
+	if err != nil {
+		return result, err
+	}
+	cmd := append([]string{}, args...)
+	for _, pkg := range pkgs {
+		cmd = append(cmd, pkg.Dir)
+	}
+	run, err := gom.runTestSuite(ctx, ctr, cmd)
+	if err != nil {
+		return result, err
+	}
+	result.Output = run.Output
+	result.Code = run.Code
+	result.Failure = run.Failure
+	result.Report = run.Report
+	return result, nil
+}
+
+// listPackages lists the packages of the source with their directory
+// relative to the module root.
+func (gom *Golang) listPackages(
+	ctx context.Context,
+	src *Directory,
+) ([]testPackage, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	out, err := ctr.
+		WithExec([]string{
+			"go", "list", "-f", "{{.ImportPath}} {{.Dir}}", "./...",
+		}).
+		Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in listing packages %w", err)
+	}
+	var pkgs []testPackage
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		rel, err := filepath.Rel(PROJ_MOUNT, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("error in resolving package dir %w", err)
+		}
+		dir := "."
+		if rel != "." {
+			dir = "./" + rel
+		}
+		pkgs = append(pkgs, testPackage{
+			ImportPath: fields[0],
+			Dir:        dir,
+			Weight:     1,
+		})
+	}
+	return pkgs, nil
+}
+
+// packageDurations sums up the elapsed time of every package from a go test
+// json report.
+func packageDurations(report string) map[string]float64 {
+	durations := make(map[string]float64)
+	scanner := bufio.NewScanner(strings.NewReader(report))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var evt testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &evt); err != nil {
+			continue
+		}
+		if len(evt.Test) == 0 && (evt.Action == "pass" || evt.Action == "fail") {
+			durations[evt.Package] += evt.Elapsed
+		}
+	}
+	return durations
+}
+
+// weighPackages assigns the recorded durations as package weights. Packages
+// missing from the report get the mean duration of the known ones.
+func weighPackages(pkgs []testPackage, durations map[string]float64) {
+	var total float64
+	var known int
+	for _, pkg := range pkgs {
+		if duration, ok := durations[pkg.ImportPath]; ok {
+			total += duration
+			known++
+		}
+	}
+	fallback := 1.0
+	if known > 0 && total > 0 {
+		fallback = total / float64(known)
+	}
+	for idx := range pkgs {
+		pkgs[idx].Weight = fallback
+		if duration, ok := durations[pkgs[idx].ImportPath]; ok && duration > 0 {
+			pkgs[idx].Weight = duration
+		}
+	}
+}
+
+// shardPackages distributes the packages over the shards, always assigning
+// the heaviest remaining package to the lightest shard. Empty shards are
+// dropped.
+func shardPackages(pkgs []testPackage, shards int) [][]testPackage {
+	sorted := append([]testPackage{}, pkgs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Weight > sorted[j].Weight
+	})
+	planned := make([][]testPackage, shards)
+	loads := make([]float64, shards)
+	for _, pkg := range sorted {
+		lightest := 0
+		for idx := range loads {
+			if loads[idx] < loads[lightest] {
+				lightest = idx
+			}
+		}
+		planned[lightest] = append(planned[lightest], pkg)
+		loads[lightest] += pkg.Weight
+	}
+	var nonEmpty [][]testPackage
+	for _, shard := range planned {
+		if len(shard) > 0 {
+			nonEmpty = append(nonEmpty, shard)
+		}
+	}
+	return nonEmpty
+}
+
+// mergeShardResults combines the output and the json reports of every shard
+// and fails when any of the shards failed.
+func mergeShardResults(results []shardResult) (*ShardedRun, error) {
+	var output strings.Builder
+	var report strings.Builder
+	var failed []string
+	for idx, result := range results {
+		status := "passed"
+		if result.Code != 0 {
+			status = fmt.Sprintf("failed, %s", result.Failure)
+			failed = append(failed, fmt.Sprintf("%d", idx))
+		}
+		fmt.Fprintf(
+			&output,
+			"=== shard %d: %d packages %s\n%s\n",
+			idx, len(result.Packages), status, result.Output,
+		)
+		report.WriteString(result.Report)
+		if len(result.Report) > 0 && !strings.HasSuffix(result.Report, "\n") {
+			report.WriteString("\n")
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf(
+			"tests failed in shards %s\n%s",
+			strings.Join(failed, ", "),
+			output.String(),
+		)
+	}
+	return &ShardedRun{
+		Output: output.String(),
+		Report: dag.Directory().
+			WithNewFile(shardReport, report.String()).
+			File(shardReport),
+	}, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const testDoxReport = "TESTDOX.md"
+
+// TestDox runs the Go tests with gotestdox and returns a human readable
+// specification of the tested behaviours grouped by package.
+func (gom *Golang) TestDox(
+	ctx context.Context,
+	// The source directory to test, Required.
+	src *Directory,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	ctr, err := gom.testContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	return ctr.WithExec(append([]string{"gotestdox"}, withDefaultPackages(args)...)).
+		Stdout(ctx)
+}
+
+// TestDoxMarkdown runs the Go tests with gotestdox and returns the
+// specification of the tested behaviours as a Markdown file.
+func (gom *Golang) TestDoxMarkdown(
+	ctx context.Context,
+	// The source directory to test, Required.
+	src *Directory,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (*File, error) {
+	spec, err := gom.TestDox(ctx, src, args)
+	if err != nil {
+		return nil, err
+	}
+	return dag.Directory().
+		WithNewFile(testDoxReport, testDoxToMarkdown(spec)).
+		File(testDoxReport), nil
+}
+
+// TestDoxFromGithub fetches a GitHub repository and returns the gotestdox
+// specification of its tests.
+func (gom *Golang) TestDoxFromGithub(
+	ctx context.Context,
+	// The GitHub repository name (e.g., "username/repo")
+	repository string,
+	// The git reference (branch, tag, or commit) to clone and test
+	gitRef string,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	return gom.TestDox(ctx, githubCheckout(repository, gitRef), args)
+}
+
+// TestDoxMarkdownFromGithub fetches a GitHub repository and returns the
+// gotestdox specification of its tests as a Markdown file.
+func (gom *Golang) TestDoxMarkdownFromGithub(
+	ctx context.Context,
+	// The GitHub repository name (e.g., "username/repo")
+	repository string,
+	// The git reference (branch, tag, or commit) to clone and test
+	gitRef string,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (*File, error) {
+	return gom.TestDoxMarkdown(ctx, githubCheckout(repository, gitRef), args)
+}
+
+// testDoxToMarkdown converts the plain text output of gotestdox, where every
+// package header is followed by its indented sentences, into a Markdown
+// document with one section per package.
+func testDoxToMarkdown(spec string) string {
+	var doc strings.Builder
+	doc.WriteString("# Test specification\n")
+	for _, line := range strings.Split(spec, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case len(trimmed) == 0:
+			continue
+		case line == trimmed && strings.HasSuffix(trimmed, ":"):
+			fmt.Fprintf(&doc, "\n## %s\n\n", strings.TrimSuffix(trimmed, ":"))
+		default:
+			fmt.Fprintf(&doc, "- %s\n", trimmed)
+		}
+	}
+	return doc.String()
+}
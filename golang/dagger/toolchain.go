@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+const autoGolangVersion = "auto"
+
+// golangVersion returns the Go version used for the source. In auto mode it
+// is the highest go or toolchain directive of the go.mod, or of every
+// module of the go.work, of the source.
+func (gom *Golang) golangVersion(
+	ctx context.Context,
+	src *Directory,
+) (string, error) {
+	if gom.GolangVersion != autoGolangVersion {
+		return gom.GolangVersion, nil
+	}
+	version, err := declaredGoVersion(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	if _, err := gom.goBase(version).Sync(ctx); err != nil {
+		return "", fmt.Errorf(
+			"source requires go %s but it is not available for the %s base, the version is likely newer than any published release %w",
+			version, gom.baseFlavour(), err,
+		)
+	}
+	if gom.baseFlavour() != flavourWolfi {
+		return version, nil
+	}
+	// wolfi installs the latest patch of the minor release, which may
+	// predate the declared one
+	out, err := gom.goBase(version).
+		WithExec([]string{"go", "env", "GOVERSION"}).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in reading the installed go version %w", err)
+	}
+	installed := strings.TrimPrefix(strings.TrimSpace(out), "go")
+	if compareGoVersions(installed, version) < 0 {
+		return "", fmt.Errorf(
+			"source requires go %s but the wolfi base only provides go %s",
+			version, installed,
+		)
+	}
+	return installed, nil
+}
+
+// declaredGoVersion reads the go and toolchain directives of the source.
+func declaredGoVersion(ctx context.Context, src *Directory) (string, error) {
+	entries, err := src.Entries(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in listing source entries %w", err)
+	}
+	if !slices.Contains(entries, "go.work") {
+		return goModVersion(ctx, src, "go.mod")
+	}
+	work, err := readWorkFile(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	var version string
+	if work.Go != nil {
+		version = work.Go.Version
+	}
+	if work.Toolchain != nil {
+		version = maxGoVersion(version, toolchainVersion(work.Toolchain.Name))
+	}
+	for _, use := range work.Use {
+		modVersion, err := goModVersion(
+			ctx,
+			src,
+			path.Join(use.Path, "go.mod"),
+		)
+		if err != nil {
+			return "", err
+		}
+		version = maxGoVersion(version, modVersion)
+	}
+	if len(version) == 0 {
+		return "", fmt.Errorf("no go version is declared in go.work or its modules")
+	}
+	return version, nil
+}
+
+// readWorkFile parses the go.work file of the source.
+func readWorkFile(ctx context.Context, src *Directory) (*modfile.WorkFile, error) {
+	content, err := src.File("go.work").Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error in reading go.work %w", err)
+	}
+	work, err := modfile.ParseWork("go.work", []byte(content), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error in parsing go.work %w", err)
+	}
+	return work, nil
+}
+
+// goModVersion returns the version required by the go and toolchain
+// directives of a go.mod file.
+func goModVersion(
+	ctx context.Context,
+	src *Directory,
+	file string,
+) (string, error) {
+	content, err := src.File(file).Contents(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error in reading %s %w", file, err)
+	}
+	mod, err := modfile.ParseLax(file, []byte(content), nil)
+	if err != nil {
+		return "", fmt.Errorf("error in parsing %s %w", file, err)
+	}
+	var version string
+	if mod.Go != nil {
+		version = mod.Go.Version
+	}
+	if mod.Toolchain != nil {
+		version = maxGoVersion(version, toolchainVersion(mod.Toolchain.Name))
+	}
+	if len(version) == 0 {
+		return "", fmt.Errorf("no go version is declared in %s", file)
+	}
+	return version, nil
+}
+
+// toolchainVersion strips the go prefix and any suffix such as
+// go1.22.6+auto from a toolchain name.
+func toolchainVersion(name string) string {
+	version, _, _ := strings.Cut(strings.TrimPrefix(name, "go"), "+")
+	return version
+}
+
+// maxGoVersion returns the higher of two Go versions such as 1.22, 1.22.6
+// or 1.23rc1, an empty version is lower than any other.
+func maxGoVersion(a, b string) string {
+	if compareGoVersions(a, b) < 0 {
+		return b
+	}
+	return a
+}
+
+func compareGoVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if len(a) == 0 {
+		return -1
+	}
+	if len(b) == 0 {
+		return 1
+	}
+	anum, apre := splitGoVersion(a)
+	bnum, bpre := splitGoVersion(b)
+	for idx := 0; idx < 3; idx++ {
+		if anum[idx] != bnum[idx] {
+			if anum[idx] < bnum[idx] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case apre == bpre:
+		return 0
+	case len(apre) == 0:
+		return 1
+	case len(bpre) == 0:
+		return -1
+	case apre < bpre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// splitGoVersion splits a Go version into its numeric parts and its
+// prerelease suffix, 1.23rc1 becomes [1 23 0] and rc1.
+func splitGoVersion(version string) ([3]int, string) {
+	var nums [3]int
+	release := version
+	var pre string
+	if idx := strings.IndexFunc(version, func(r rune) bool {
+		return r != '.' && (r < '0' || r > '9')
+	}); idx >= 0 {
+		release, pre = version[:idx], version[idx:]
+	}
+	for idx, part := range strings.SplitN(release, ".", 3) {
+		nums[idx], _ = strconv.Atoi(part)
+	}
+	return nums, pre
+}
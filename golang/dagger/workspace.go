@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// stageResult is the outcome of a single check run on a module.
+type stageResult struct {
+	Module   string        `json:"module"`
+	Stage    string        `json:"stage"`
+	Required bool          `json:"required"`
+	Code     int           `json:"code"`
+	Output   string        `json:"output"`
+	Duration time.Duration `json:"duration"`
+}
+
+// MarshalJSON encodes the duration in its human readable form.
+func (res *stageResult) MarshalJSON() ([]byte, error) {
+	type result stageResult
+	return json.Marshal(struct {
+		*result
+		Duration string `json:"duration"`
+	}{
+		result:   (*result)(res),
+		Duration: res.Duration.Round(time.Second).String(),
+	})
+}
+
+// TestWorkspace tests, vets and lints every module listed in the go.work of
+// the source concurrently and reports the results per module. The rerun
+// policy of WithRerunFails applies to the tests.
+func (gom *Golang) TestWorkspace(
+	ctx context.Context,
+	// The source directory containing the go.work file, Required.
+	src *Directory,
+	// An optional string specifying the version of golangci-lint to use
+	// +optional
+	// +default="v1.55.2-alpine"
+	lintVersion string,
+	// An optional slice of strings representing additional arguments to the go test command
+	// +optional
+	args []string,
+) (string, error) {
+	work, err := readWorkFile(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	if len(work.Use) == 0 {
+		return "", fmt.Errorf("go.work does not use any module")
+	}
+	goCtr, err := gom.sourceContainer(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	lintCtr, cfgPath, err := gom.lintContainer(ctx, lintVersion, src, nil)
+	if err != nil {
+		return "", err
+	}
+	var results []*stageResult
+	grp, gctx := errgroup.WithContext(ctx)
+	for _, use := range work.Use {
+		dir := path.Join(PROJ_MOUNT, use.Path)
+		// go mod download is not available in workspace mode, the modules
+		// are fetched by the checks themselves
+		modCtr := goCtr.WithWorkdir(dir)
+		stages := map[string]func(context.Context) (string, int, error){
+			"test": func(ctx context.Context) (string, int, error) {
+				run, err := gom.runTestSuite(ctx, modCtr, withDefaultPackages(args))
+				if err != nil {
+					return "", 0, err
+				}
+				return run.Output, run.Code, nil
+			},
+			"vet": func(ctx context.Context) (string, int, error) {
+				return execStatus(
+					ctx,
+					statusExec([]string{"go", "vet", "./..."})(modCtr),
+				)
+			},
+			"lint": func(ctx context.Context) (string, int, error) {
+				return execStatus(ctx, statusExec([]string{
+					"golangci-lint", "run", "-c", cfgPath, "./...",
+				})(lintCtr.WithWorkdir(dir)))
+			},
+		}
+		for stage, check := range stages {
+			result := &stageResult{Module: use.Path, Stage: stage, Required: true}
+			results = append(results, result)
+			check := check
+			grp.Go(func() error {
+				start := time.Now()
+				output, code, err := check(gctx)
+				if err != nil {
+					return fmt.Errorf(
+						"error in running %s of %s %w",
+						result.Stage, result.Module, err,
+					)
+				}
+				result.Output = output
+				result.Code = code
+				result.Duration = time.Since(start)
+				return nil
+			})
+		}
+	}
+	if err := grp.Wait(); err != nil {
+		return "", err
+	}
+	return workspaceReport(results)
+}
+
+// withDefaultPackages tests every package of the module unless the arguments
+// select packages themselves.
+func withDefaultPackages(args []string) []string {
+	pkgs, flags := splitTestArgs(args)
+	return append(pkgs, flags...)
+}
+
+// workspaceReport renders the per module results, it fails when any check
+// failed.
+func workspaceReport(results []*stageResult) (string, error) {
+	report, err := stagesReport(sortedResults(results), "markdown")
+	if err != nil {
+		return "", err
+	}
+	if failed := failedStages(results); len(failed) > 0 {
+		return report, fmt.Errorf(
+			"%d checks failed\n%s",
+			len(failed),
+			report,
+		)
+	}
+	return report, nil
+}
+
+// stagesReport renders the results as a Markdown table followed by the
+// output of every failed check, or as a JSON document.
+func stagesReport(results []*stageResult, format string) (string, error) {
+	if format == "json" {
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error in encoding stage results %w", err)
+		}
+		return string(out), nil
+	}
+	var report strings.Builder
+	var failures strings.Builder
+	report.WriteString("| module | check | required | status | duration |\n")
+	report.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, result := range results {
+		status := "passed"
+		if result.Code != 0 {
+			status = "failed"
+			fmt.Fprintf(
+				&failures,
+				"\n### %s %s\n\n```\n%s\n```\n",
+				result.Module, result.Stage, result.Output,
+			)
+		}
+		fmt.Fprintf(
+			&report,
+			"| %s | %s | %t | %s | %s |\n",
+			result.Module,
+			result.Stage,
+			result.Required,
+			status,
+			result.Duration.Round(time.Second),
+		)
+	}
+	report.WriteString(failures.String())
+	return report.String(), nil
+}
+
+// failedStages lists the required checks that failed.
+func failedStages(results []*stageResult) []string {
+	var failed []string
+	for _, result := range sortedResults(results) {
+		if result.Required && result.Code != 0 {
+			failed = append(failed, path.Join(result.Module, result.Stage))
+		}
+	}
+	return failed
+}
+
+// sortedResults orders the results by module and check.
+func sortedResults(results []*stageResult) []*stageResult {
+	sorted := append([]*stageResult{}, results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Module != sorted[j].Module {
+			return sorted[i].Module < sorted[j].Module
+		}
+		return sorted[i].Stage < sorted[j].Stage
+	})
+	return sorted
+}